@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Admin API server shutdown timeout
+const AdminShutdownTimeout = 5 * time.Second
+
+// Admin API load request
+type loadRequest struct {
+	Report   string   `json:"report"`
+	Logins   []string `json:"logins"`
+	DateFrom string   `json:"datefrom"`
+	DateTo   string   `json:"dateto"`
+}
+
+// Admin API server
+type adminServer struct {
+	ctx   context.Context
+	wg    *sync.WaitGroup
+	token string
+}
+
+// Start admin API server if enabled. Server is stopped when context is done.
+// Loads started by API are tracked by wait group
+func startAdmin(ctx context.Context, wg *sync.WaitGroup) error {
+	conf := AppConfig.Get().Admin
+	if !conf.Enabled {
+		return nil
+	}
+	token, err := resolveSecret(conf.Token)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	if token == "" {
+		return errors.New("admin token is empty")
+	}
+	a := &adminServer{ctx: ctx, wg: wg, token: token}
+	server := &http.Server{
+		Addr:              conf.Listen,
+		Handler:           a.auth(a.routes()),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			Log.Error("Admin API failed", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), AdminShutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	Log.Info("Admin API started", conf.Listen)
+	return nil
+}
+
+// Returns admin API routes
+func (a *adminServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/jobs", a.listJobs)
+	mux.HandleFunc("GET /api/jobs/{id}", a.getJob)
+	mux.HandleFunc("POST /api/jobs/{id}/cancel", a.cancelJob)
+	mux.HandleFunc("GET /api/jobs/{id}/file", a.jobFile)
+	mux.HandleFunc("POST /api/loads", a.startLoad)
+	return mux
+}
+
+// Check bearer token of request
+func (a *adminServer) auth(next http.Handler) http.Handler {
+	expected := []byte("Bearer " + a.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// List jobs: GET /api/jobs?report=name&login=login&status=status
+func (a *adminServer) listJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	items := []JobInfo{}
+	for _, baseStruct := range AppJobs.List() {
+		info := baseStruct.Info()
+		if query.Has("report") && info.Report != query.Get("report") {
+			continue
+		}
+		if query.Has("login") && info.Login != query.Get("login") {
+			continue
+		}
+		if query.Has("status") && info.Status != query.Get("status") {
+			continue
+		}
+		items = append(items, info)
+	}
+	writeJson(w, http.StatusOK, items)
+}
+
+// Get job: GET /api/jobs/{id}
+func (a *adminServer) getJob(w http.ResponseWriter, r *http.Request) {
+	baseStruct := a.job(w, r)
+	if baseStruct == nil {
+		return
+	}
+	writeJson(w, http.StatusOK, baseStruct.Info())
+}
+
+// Cancel job: POST /api/jobs/{id}/cancel
+func (a *adminServer) cancelJob(w http.ResponseWriter, r *http.Request) {
+	baseStruct := a.job(w, r)
+	if baseStruct == nil {
+		return
+	}
+	if !baseStruct.Cancel() {
+		writeError(w, http.StatusConflict, "job is already processed")
+		return
+	}
+	Log.Info("Job canceled by admin API", baseStruct.Id, baseStruct.Login)
+	writeJson(w, http.StatusOK, baseStruct.Info())
+}
+
+// Download last response file of job: GET /api/jobs/{id}/file
+func (a *adminServer) jobFile(w http.ResponseWriter, r *http.Request) {
+	baseStruct := a.job(w, r)
+	if baseStruct == nil {
+		return
+	}
+	info := baseStruct.Info()
+	if info.FilePath == "" {
+		writeError(w, http.StatusNotFound, "job has no output file")
+		return
+	}
+	http.ServeFile(w, r, info.FilePath)
+}
+
+// Start load of report spec: POST /api/loads
+// {"report": "name", "logins": ["login"], "datefrom": "YYYY-MM-DD", "dateto": "YYYY-MM-DD"}
+func (a *adminServer) startLoad(w http.ResponseWriter, r *http.Request) {
+	req := loadRequest{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+	spec, ok := AppConfig.Get().ReportSpec(req.Report)
+	if !ok {
+		writeError(w, http.StatusNotFound, "report spec not found: "+req.Report)
+		return
+	}
+	opts := LoadOptions{Logins: req.Logins, DateFrom: req.DateFrom, DateTo: req.DateTo}
+	err = opts.Validate()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	unlock, err := lockReport(a.ctx, spec.Name)
+	if errors.Is(err, ErrReportRunning) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer unlock()
+		err := loadLocked(a.ctx, spec, opts)
+		if err != nil {
+			Log.Error("Report load failed", spec.Name, err)
+			return
+		}
+		Log.Info("Report load finished", spec.Name)
+	}()
+	Log.Info("Report load started by admin API", spec.Name, req.Logins, req.DateFrom, req.DateTo)
+	writeJson(w, http.StatusAccepted, map[string]any{"report": spec.Name, "status": "started"})
+}
+
+// Returns job by path id or writes error and returns nil
+func (a *adminServer) job(w http.ResponseWriter, r *http.Request) *BaseStruct {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid job id")
+		return nil
+	}
+	baseStruct := AppJobs.Get(id)
+	if baseStruct == nil {
+		writeError(w, http.StatusNotFound, "job not found")
+		return nil
+	}
+	return baseStruct
+}
+
+// Write JSON response
+func writeJson(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+	}
+}
+
+// Write JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJson(w, status, map[string]string{"error": message})
+}
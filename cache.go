@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/cache"
+	"github.com/AlekseiGrigorev/ydloader/internal/report"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Set deterministic report name of job: fingerprint of login, report request and headers
+func setReportName(baseStruct *BaseStruct) error {
+	headers := map[string]string{}
+	err := json.Unmarshal([]byte(baseStruct.Headers), &headers)
+	if err != nil {
+		return err
+	}
+	// Header is set by post
+	headers["returnMoneyInMicros"] = strconv.FormatBool(AppConfig.Get().Money.Micros)
+	name, err := report.Fingerprint(baseStruct.Login, baseStruct.Body, headers)
+	if err != nil {
+		return err
+	}
+	body, err := report.SetReportName(baseStruct.Body, name)
+	if err != nil {
+		return err
+	}
+	baseStruct.ReportName = name
+	baseStruct.Body = body
+	return nil
+}
+
+// Returns reports cache, nil if cache is disabled. Sandbox reports are cached in separate directory
+func reportCache() *cache.Cache {
+	conf := AppConfig.Get().Cache
+	if !conf.Enabled {
+		return nil
+	}
+	dir := conf.Dir
+	if AppConfig.Get().IsSandbox() {
+		dir = filepath.Join(dir, sandboxDir)
+	}
+	return cache.New(dir, time.Duration(conf.Ttl)*time.Second)
+}
+
+// Mark jobs with valid cached reports processed, cached reports are not fetched
+func applyCache(structs []*BaseStruct) {
+	reports := reportCache()
+	if reports == nil {
+		return
+	}
+	for _, baseStruct := range structs {
+		entry, ok := reports.Get(baseStruct.ReportName, time.Now())
+		if !ok {
+			continue
+		}
+		baseStruct.mu.Lock()
+		baseStruct.Processed = true
+		baseStruct.FilePath = entry.FilePath
+		baseStruct.mu.Unlock()
+		jobLog(baseStruct).Info("Report cached", entry.FilePath, "downloaded at", entry.DownloadedAt.Format(time.DateTime))
+	}
+}
+
+// Store downloaded report of job in cache
+func putCache(baseStruct *BaseStruct, filePath string) {
+	reports := reportCache()
+	if reports == nil {
+		return
+	}
+	entry := cache.Entry{
+		ReportName:   baseStruct.ReportName,
+		Login:        baseStruct.Login,
+		FilePath:     filePath,
+		DownloadedAt: time.Now(),
+	}
+	err := reports.Put(entry)
+	if err != nil {
+		jobLog(baseStruct).Error(err, trace.GetTrace())
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/changes"
+	"github.com/AlekseiGrigorev/ydloader/internal/config"
+	"github.com/AlekseiGrigorev/ydloader/internal/direct"
+	"github.com/AlekseiGrigorev/ydloader/internal/report"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Keep jobs of logins with statistics changed since the last check of Direct Changes service.
+// Kept jobs load only changed campaigns from the earliest changed date.
+// Jobs of logins without stored check timestamp load all report dates
+func applyChanges(ctx context.Context, spec config.Report, structs []*BaseStruct) []*BaseStruct {
+	store := changes.NewStore(&AppDb, AppConfig.Get().Environment)
+	result := []*BaseStruct{}
+	for _, baseStruct := range structs {
+		keep, err := checkChanges(ctx, store, spec.Name, baseStruct)
+		if err != nil {
+			// Job loads all report dates, check timestamp is not changed
+			jobLog(baseStruct).Error("Changes check failed", err, trace.GetTrace())
+			keep = true
+		}
+		if keep {
+			result = append(result, baseStruct)
+		}
+	}
+	return result
+}
+
+// Check changes of job login and limit job to changed dates and campaigns.
+// Returns false if login has no changes in report dates
+func checkChanges(ctx context.Context, store *changes.Store, reportName string, baseStruct *BaseStruct) (bool, error) {
+	httpConfig := AppConfig.Get().Http
+	timeout := time.Duration(httpConfig.Timeout) * time.Second
+	client := direct.NewClient(AppConfig.Get().ApiUrl(), baseStruct.Token, timeout).WithLogin(baseStruct.Login)
+	lastCheck, err := store.LastCheck(ctx, reportName, baseStruct.Login)
+	if err != nil {
+		return false, err
+	}
+	if lastCheck == "" {
+		baseStruct.ChangesTimestamp, err = client.Timestamp(ctx)
+		if err != nil {
+			return false, err
+		}
+		jobLog(baseStruct).Info("First changes check, load all dates")
+		return true, nil
+	}
+	campaigns, timestamp, err := client.CheckCampaigns(ctx, lastCheck)
+	if err != nil {
+		return false, err
+	}
+	changed := []int64{}
+	for _, campaign := range campaigns {
+		if slices.Contains(campaign.ChangesIn, direct.ChangesInStat) {
+			changed = append(changed, campaign.CampaignId)
+		}
+	}
+	// Campaign ids are sent in chunks of changes.check limit, so large agency logins are checked too
+	stats := []direct.CampaignStat{}
+	if len(changed) > 0 {
+		stats, err = client.CheckStat(ctx, changed, lastCheck)
+		if err != nil {
+			return false, err
+		}
+	}
+	borderDate := ""
+	campaignIds := []string{}
+	skipped := false
+	for _, stat := range stats {
+		// Statistics changed after last report date are loaded by next runs,
+		// check timestamp is not advanced, so next runs get these changes again
+		if stat.BorderDate > baseStruct.DateTo {
+			skipped = true
+			continue
+		}
+		if borderDate == "" || stat.BorderDate < borderDate {
+			borderDate = stat.BorderDate
+		}
+		campaignIds = append(campaignIds, strconv.FormatInt(stat.CampaignId, 10))
+	}
+	if skipped {
+		timestamp = ""
+	}
+	if len(campaignIds) == 0 {
+		jobLog(baseStruct).Info("No changes since", lastCheck)
+		if skipped {
+			return false, nil
+		}
+		return false, store.SetLastCheck(ctx, reportName, baseStruct.Login, timestamp)
+	}
+	dateFrom := max(borderDate, baseStruct.DateFrom)
+	body, err := report.SetDates(baseStruct.Body, dateFrom, baseStruct.DateTo)
+	if err != nil {
+		return false, err
+	}
+	body, err = report.AddFilter(body, report.Filter{Field: "CampaignId", Operator: "IN", Values: campaignIds})
+	if err != nil {
+		return false, err
+	}
+	jobLog(baseStruct).Info("Changes since", lastCheck, "campaigns", len(campaignIds), "from", dateFrom)
+	baseStruct.DateFrom = dateFrom
+	baseStruct.Body = body
+	baseStruct.ChangesTimestamp = timestamp
+	return true, setReportName(baseStruct)
+}
+
+// Store changes check timestamps of successfully loaded jobs
+func saveChanges(reportName string, structs []*BaseStruct) {
+	store := changes.NewStore(&AppDb, AppConfig.Get().Environment)
+	for _, baseStruct := range structs {
+		baseStruct.mu.Lock()
+		done := baseStruct.Processed && !baseStruct.Failed
+		login, timestamp := baseStruct.Login, baseStruct.ChangesTimestamp
+		baseStruct.mu.Unlock()
+		if !done || timestamp == "" {
+			continue
+		}
+		err := store.SetLastCheck(context.Background(), reportName, login, timestamp)
+		if err != nil {
+			Log.Error(err, trace.GetTrace())
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/config"
+)
+
+// Run config command: config check
+// Prints effective configuration with masked secrets and validation result
+func runConfig(path string, args []string) error {
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Arg(0) != "check" {
+		return errors.New("usage: ydloader [--config path] config check")
+	}
+	loader := config.NewLoader(path)
+	appConfig, err := loader.Load()
+	if err != nil {
+		return err
+	}
+	file := loader.File()
+	if file == "" {
+		file = "not found, defaults and environment are used"
+	}
+	fmt.Println("# config file:", file)
+	out, err := yaml.Marshal(appConfig.Masked())
+	if err != nil {
+		return err
+	}
+	os.Stdout.Write(out)
+	err = appConfig.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	fmt.Println("# configuration is valid")
+	return nil
+}
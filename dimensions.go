@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/direct"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+	"github.com/AlekseiGrigorev/ydloader/models/ydirectlogins"
+)
+
+// Lock name of dimension sync, syncs never overlap like runs of report spec
+const dimensionsLock = "@dimensions"
+
+// Run dimensions command: dimensions [-entities campaigns,ads] [-logins a,b]
+// Syncs dimensions of logins to files <input>/<login>/<dimension>-<time>.json
+func runDimensions(args []string) error {
+	conf := AppConfig.Get().Dimensions
+	flags := flag.NewFlagSet("dimensions", flag.ContinueOnError)
+	entities := flags.String("entities", strings.Join(conf.Entities, ","), "comma separated dimensions to sync")
+	logins := flags.String("logins", "", "comma separated logins to sync, all logins if empty")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	names := strings.Split(*entities, ",")
+	for _, name := range names {
+		if !slices.Contains(direct.DimensionNames, name) {
+			return fmt.Errorf("unknown dimension %q, expected %s", name, strings.Join(direct.DimensionNames, ", "))
+		}
+	}
+	opts := LoadOptions{}
+	if *logins != "" {
+		opts.Logins = strings.Split(*logins, ",")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	unlock, err := lockReport(ctx, dimensionsLock)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	defer unlock()
+	return syncDimensions(ctx, names, opts)
+}
+
+// Returns logins with tokens from login source
+func loginTokens(opts LoadOptions) ([]*ydirectlogins.AllIntegrationsLogin, error) {
+	resetCurrentTokens()
+	result := []*ydirectlogins.AllIntegrationsLogin{}
+	if AppConfig.Get().IsSandbox() {
+		logins, err := getSandboxLogins()
+		if err != nil {
+			return nil, err
+		}
+		for _, login := range logins {
+			if opts.hasLogin(login.Login) {
+				result = append(result, login)
+			}
+		}
+		return result, nil
+	}
+	if IntegrationId > 0 {
+		token, err := getToken(IntegrationId)
+		if err != nil {
+			return nil, err
+		}
+		logins, err := getLogins(IntegrationId)
+		if err != nil {
+			return nil, err
+		}
+		for _, login := range logins {
+			if opts.hasLogin(login.Login) {
+				result = append(result, &ydirectlogins.AllIntegrationsLogin{IntegrationId: IntegrationId, Login: login.Login, Token: token})
+			}
+		}
+		return result, nil
+	}
+	logins, err := getAllLogins()
+	if err != nil {
+		return nil, err
+	}
+	for _, login := range logins {
+		if opts.hasLogin(login.Login) {
+			result = append(result, login)
+		}
+	}
+	return result, nil
+}
+
+// Sync dimensions of all logins, max dimensions.concurrency logins at the same time
+func syncDimensions(ctx context.Context, names []string, opts LoadOptions) error {
+	logins, err := loginTokens(opts)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	if len(logins) == 0 {
+		err = errors.New("no logins to sync")
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	conf := AppConfig.Get().Dimensions
+	slots := make(chan struct{}, conf.Concurrency)
+	failed := 0
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, login := range logins {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			err := syncLoginDimensions(ctx, login, names, int64(conf.PageLimit))
+			if err != nil {
+				Log.Error("Dimensions sync failed", "login", login.Login, err)
+				mu.Lock()
+				failed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if failed > 0 {
+		return fmt.Errorf("dimensions sync failed for %d of %d logins", failed, len(logins))
+	}
+	return nil
+}
+
+// Sync dimensions of login: campaigns are requested first, other dimensions are requested by campaign ids
+func syncLoginDimensions(ctx context.Context, login *ydirectlogins.AllIntegrationsLogin, names []string, pageLimit int64) error {
+	httpConfig := AppConfig.Get().Http
+	timeout := time.Duration(httpConfig.Timeout) * time.Second
+	client := direct.NewClient(AppConfig.Get().ApiUrl(), login.Token, timeout).WithLogin(login.Login)
+	campaigns, err := client.Dimension(ctx, direct.DimensionCampaigns, nil, pageLimit)
+	if err != nil {
+		return err
+	}
+	campaignIds := []int64{}
+	for _, item := range campaigns {
+		campaign := struct{ Id int64 }{}
+		err = json.Unmarshal(item, &campaign)
+		if err != nil {
+			return err
+		}
+		campaignIds = append(campaignIds, campaign.Id)
+	}
+	for _, name := range direct.DimensionNames {
+		if !slices.Contains(names, name) {
+			continue
+		}
+		items := campaigns
+		if name != direct.DimensionCampaigns {
+			items, err = client.Dimension(ctx, name, campaignIds, pageLimit)
+			if err != nil {
+				return err
+			}
+		}
+		byId, err := keyById(items)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		path, err := writeDimension(login.Login, name, byId)
+		if err != nil {
+			return err
+		}
+		Log.Info("Dimension synced", "login", login.Login, name, len(byId), path)
+	}
+	return nil
+}
+
+// Returns dimension items by item id
+func keyById(items []json.RawMessage) (map[string]json.RawMessage, error) {
+	byId := make(map[string]json.RawMessage, len(items))
+	for _, item := range items {
+		key := struct{ Id int64 }{}
+		err := json.Unmarshal(item, &key)
+		if err != nil {
+			return nil, err
+		}
+		byId[strconv.FormatInt(key.Id, 10)] = item
+	}
+	return byId, nil
+}
+
+// Write dimension items of login to input data directory
+// Returns file name
+func writeDimension(login string, name string, byId map[string]json.RawMessage) (string, error) {
+	path, err := createDir(&BaseStruct{Login: login})
+	if err != nil {
+		return "", err
+	}
+	content, err := json.MarshalIndent(byId, "", "  ")
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return "", err
+	}
+	return writeNewFile(path, name+"-"+time.Now().Format("20060102150405"), ".json", content)
+}
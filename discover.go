@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/direct"
+	"github.com/AlekseiGrigorev/ydloader/internal/logins"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Login discovery result of integration
+type discovery struct {
+	IntegrationId int
+	Agency        bool
+	New           []string // Active API clients missing in login source
+	Archived      []string // Logins of login source archived or missing in API
+	Known         int      // Logins of login source active in API
+	Active        int      // Active API clients
+}
+
+// Run discover command: discover [-integration id] [-sync] [-unlink]
+// Compares API clients of integration tokens with login source, optionally adds new and unlinks archived logins
+func runDiscover(args []string) error {
+	flags := flag.NewFlagSet("discover", flag.ContinueOnError)
+	integrationId := flags.Int("integration", 0, "discover only integration id")
+	sync := flags.Bool("sync", false, "add new logins to login source")
+	unlink := flags.Bool("unlink", false, "unlink archived and missing logins from integration")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if AppConfig.Get().IsSandbox() {
+		return errors.New("discover works with login source of production environment")
+	}
+	ctx := context.Background()
+	source := logins.NewStore(&AppDb)
+	items, err := source.Integrations(ctx)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	failed := 0
+	for _, integration := range items {
+		if *integrationId > 0 && integration.Id != *integrationId {
+			continue
+		}
+		result, err := discover(ctx, source, integration.Id, integration.Token)
+		if err != nil {
+			failed++
+			Log.Error("Discovery failed", "integration", integration.Id, err)
+			fmt.Printf("integration %d: discovery failed: %s\n", integration.Id, err)
+			continue
+		}
+		printDiscovery(result)
+		err = applyDiscovery(ctx, source, result, *sync, *unlink)
+		if err != nil {
+			Log.Error(err, trace.GetTrace())
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("discovery failed for %d integrations", failed)
+	}
+	return nil
+}
+
+// Discover API clients of integration token and compare them with login source
+func discover(ctx context.Context, source logins.Source, integrationId int, token string) (*discovery, error) {
+	value, err := resolveStoredSecret(token)
+	if err != nil {
+		return nil, err
+	}
+	token = effectiveToken(integrationId, value)
+	httpConfig := AppConfig.Get().Http
+	client := direct.NewClient(AppConfig.Get().ApiUrl(), token, time.Duration(httpConfig.Timeout)*time.Second)
+	result := &discovery{IntegrationId: integrationId, Agency: true}
+	clients, err := client.AgencyClients(ctx)
+	// Other errors (units, rate limits, server errors) fail discovery, so logins are not unlinked by mistake
+	if direct.IsNotAgencyError(err) {
+		result.Agency = false
+		clients, err = client.Clients(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	stored, err := source.Logins(ctx, integrationId)
+	if err != nil {
+		return nil, err
+	}
+	storedLogins := []string{}
+	for _, login := range stored {
+		storedLogins = append(storedLogins, login.Login)
+	}
+	active := []string{}
+	for _, item := range clients {
+		if item.Archived != direct.ArchivedYes {
+			active = append(active, item.Login)
+		}
+	}
+	compareLogins(result, storedLogins, active)
+	return result, nil
+}
+
+// Compare active API clients with logins of login source.
+// Logins are case-insensitive, stored logins keep their spelling, so they are unlinked as stored
+func compareLogins(result *discovery, stored []string, active []string) {
+	storedSet := map[string]bool{}
+	for _, login := range stored {
+		storedSet[strings.ToLower(login)] = true
+	}
+	activeSet := map[string]bool{}
+	for _, login := range active {
+		activeSet[strings.ToLower(login)] = true
+	}
+	result.Active = len(active)
+	for _, login := range active {
+		if !storedSet[strings.ToLower(login)] {
+			result.New = append(result.New, login)
+		}
+	}
+	for _, login := range stored {
+		if activeSet[strings.ToLower(login)] {
+			result.Known++
+		} else {
+			result.Archived = append(result.Archived, login)
+		}
+	}
+}
+
+// Print discovery result
+func printDiscovery(result *discovery) {
+	kind := "advertiser"
+	if result.Agency {
+		kind = "agency"
+	}
+	fmt.Printf("integration %d (%s): %d known, %d new, %d archived or missing\n",
+		result.IntegrationId, kind, result.Known, len(result.New), len(result.Archived))
+	for _, login := range result.New {
+		fmt.Printf("    + %s\n", login)
+	}
+	for _, login := range result.Archived {
+		fmt.Printf("    - %s\n", login)
+	}
+}
+
+// Add new logins and unlink archived logins in login source
+func applyDiscovery(ctx context.Context, source logins.Source, result *discovery, sync bool, unlink bool) error {
+	if sync {
+		for _, login := range result.New {
+			err := source.Add(ctx, result.IntegrationId, login)
+			if err != nil {
+				return err
+			}
+			Log.Info("Login added", "integration", result.IntegrationId, login)
+		}
+	}
+	if unlink && len(result.Archived) > 0 && result.Active == 0 {
+		return fmt.Errorf("integration %d: API returned no active clients, logins are not unlinked", result.IntegrationId)
+	}
+	if unlink {
+		for _, login := range result.Archived {
+			err := source.Unlink(ctx, result.IntegrationId, login)
+			if err != nil {
+				return err
+			}
+			Log.Info("Login unlinked", "integration", result.IntegrationId, login)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompareLogins(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   []string
+		active   []string
+		known    int
+		new      []string
+		archived []string
+	}{
+		{"same logins", []string{"client-a", "client-b"}, []string{"client-a", "client-b"}, 2, nil, nil},
+		{"logins differ in case", []string{"Client-A", "client-b"}, []string{"client-a", "CLIENT-B"}, 2, nil, nil},
+		{"new login", []string{"client-a"}, []string{"Client-A", "client-c"}, 1, []string{"client-c"}, nil},
+		{"archived login keeps stored spelling", []string{"Client-A", "Client-D"}, []string{"client-a"}, 1, nil, []string{"Client-D"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &discovery{}
+			compareLogins(result, tt.stored, tt.active)
+			if result.Known != tt.known || !slices.Equal(result.New, tt.new) || !slices.Equal(result.Archived, tt.archived) {
+				t.Errorf("compareLogins(%v, %v) = known %d, new %v, archived %v, want known %d, new %v, archived %v",
+					tt.stored, tt.active, result.Known, result.New, result.Archived, tt.known, tt.new, tt.archived)
+			}
+			if result.Active != len(tt.active) {
+				t.Errorf("active = %d, want %d", result.Active, len(tt.active))
+			}
+		})
+	}
+}
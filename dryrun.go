@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/report"
+	"github.com/AlekseiGrigorev/ydloader/internal/secret"
+	"github.com/AlekseiGrigorev/ydloader/internal/tokens"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Error of dry run with invalid requests
+var ErrInvalidRequests = errors.New("dry run: invalid report requests")
+
+// Run load in dry run mode: [load] [-report name] [-logins a,b] [-datefrom date] [-dateto date]
+// Resolves logins, renders and validates report requests of every job and prints them with masked tokens
+// or writes them to out directory. Report API is not called
+func runDryRun(args []string, out string) error {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if args[0] != "load" {
+			return errors.New("--dry-run works only with load command")
+		}
+		args = args[1:]
+	}
+	spec, opts, err := parseLoadFlags(args)
+	if err != nil {
+		return err
+	}
+	// Stored tokens are used, expired tokens are not refreshed
+	AppTokens = tokens.NewStore(&AppDb)
+	detectTimezones = false
+	structs, err := createJobs(spec, opts)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	invalid := 0
+	for i, baseStruct := range structs {
+		headers, body, err := dryRunRequest(baseStruct)
+		status := "ok"
+		if err != nil {
+			invalid++
+			status = "invalid:\n" + err.Error()
+		}
+		if out == "" {
+			fmt.Printf("# job %d: report %s, login %s, dates %s..%s\n", i+1, spec.Name, baseStruct.Login, baseStruct.DateFrom, baseStruct.DateTo)
+			fmt.Printf("## headers\n%s\n## body\n%s\n## validation: %s\n\n", headers, body, status)
+			continue
+		}
+		err = writeDryRun(out, spec.Name, i+1, baseStruct, headers, body, status)
+		if err != nil {
+			Log.Error(err, trace.GetTrace())
+			return err
+		}
+	}
+	fmt.Printf("Dry run of report %s: %d jobs, %d invalid\n", spec.Name, len(structs), invalid)
+	if invalid > 0 {
+		return ErrInvalidRequests
+	}
+	return nil
+}
+
+// Returns job headers with masked token, body and validation errors
+func dryRunRequest(baseStruct *BaseStruct) (string, string, error) {
+	headers := baseStruct.Headers
+	if baseStruct.Token != "" {
+		headers = strings.ReplaceAll(headers, baseStruct.Token, secret.Mask(baseStruct.Token))
+	}
+	errs := []error{}
+	for _, placeholder := range []string{"@AuthorizationToken", "@Client-Login", "@DateFrom", "@DateTo", "@ReportName"} {
+		if strings.Contains(baseStruct.Headers+baseStruct.Body, placeholder) {
+			errs = append(errs, fmt.Errorf("placeholder %s is not replaced", placeholder))
+		}
+	}
+	headerMap := map[string]string{}
+	err := json.Unmarshal([]byte(baseStruct.Headers), &headerMap)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("headers: %w", err))
+	} else {
+		err = report.ValidateHeaders(headerMap)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	req, err := report.ParseRequest([]byte(baseStruct.Body))
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		err = req.Params.Validate()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return headers, baseStruct.Body, errors.Join(errs...)
+}
+
+// Write dry run request of job to out/<report>/<n>-<login>.headers.json, .body.json and .result.txt
+func writeDryRun(out string, report string, n int, baseStruct *BaseStruct, headers string, body string, status string) error {
+	dir := filepath.Join(out, report)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(dir, strconv.Itoa(n)+"-"+baseStruct.Login)
+	files := map[string]string{
+		name + ".headers.json": headers,
+		name + ".body.json":    body,
+		name + ".result.txt":   status + "\n",
+	}
+	for path, content := range files {
+		err = os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/config"
+	"github.com/AlekseiGrigorev/ydloader/internal/goals"
+	"github.com/AlekseiGrigorev/ydloader/internal/report"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Returns goals of integration: goals of config, goals of database if enabled
+func integrationGoals(integrationId int) ([]int64, error) {
+	conf := AppConfig.Get().Goals
+	if ids, ok := conf.Integrations[strconv.Itoa(integrationId)]; ok {
+		return ids, nil
+	}
+	if !conf.Db || integrationId == 0 {
+		return nil, nil
+	}
+	return goals.NewStore(&AppDb).Goals(context.Background(), integrationId)
+}
+
+// Add goals and attribution models of report spec to job bodies.
+// Goals of integration are used if report spec goals are not set and report spec uses integration goals
+func applyGoals(spec config.Report, structs []*BaseStruct) error {
+	if len(spec.Goals) == 0 && !spec.IntegrationGoals {
+		return nil
+	}
+	byIntegration := map[int][]int64{}
+	for _, baseStruct := range structs {
+		ids := spec.Goals
+		if len(ids) == 0 {
+			var ok bool
+			ids, ok = byIntegration[baseStruct.IntegrationId]
+			if !ok {
+				var err error
+				ids, err = integrationGoals(baseStruct.IntegrationId)
+				if err != nil {
+					return err
+				}
+				byIntegration[baseStruct.IntegrationId] = ids
+			}
+		}
+		if len(ids) == 0 {
+			jobLog(baseStruct).Warn("No goals of integration", baseStruct.IntegrationId)
+			continue
+		}
+		body, err := report.SetGoals(baseStruct.Body, ids, spec.AttributionModels)
+		if err != nil {
+			return err
+		}
+		baseStruct.Body = body
+	}
+	return nil
+}
+
+// Write goals table of report with goal columns next to response file: <response file>-goals.tsv
+func writeGoals(baseStruct *BaseStruct, filePath string, table *report.Table) error {
+	if !table.HasGoals() {
+		return nil
+	}
+	goalsPath := strings.TrimSuffix(filePath, ".txt") + "-goals.tsv"
+	err := os.WriteFile(goalsPath, []byte(table.Goals().String()), 0777)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	jobLog(baseStruct).Info("Goals written", goalsPath)
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/history"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Start run of report spec in run history
+// Returns run id or 0 if history is disabled or failed
+func startRun(report string) int64 {
+	if AppHistory == nil {
+		return 0
+	}
+	runId, err := AppHistory.StartRun(context.Background(), report)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return 0
+	}
+	return runId
+}
+
+// Finish run with jobs results: send notifications and store run in run history
+func finishRun(report string, runId int64, structs []*BaseStruct) {
+	run := &history.Run{Id: runId, Report: report, JobsTotal: len(structs)}
+	for _, baseStruct := range structs {
+		baseStruct.mu.Lock()
+		if baseStruct.Processed && !baseStruct.Failed {
+			run.JobsSucceeded++
+		} else {
+			run.JobsFailed++
+		}
+		if baseStruct.QualityError != "" {
+			run.QualityFailed++
+		}
+		baseStruct.mu.Unlock()
+	}
+	switch {
+	case run.JobsFailed == 0:
+		run.Status = history.StatusSuccess
+	case run.JobsSucceeded == 0:
+		run.Status = history.StatusFailed
+	default:
+		run.Status = history.StatusPartial
+	}
+	notifyRun(run)
+	if AppHistory == nil || runId == 0 {
+		return
+	}
+	err := AppHistory.FinishRun(context.Background(), run)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+	}
+}
+
+// Returns new attempt for job current try
+func newAttempt(baseStruct *BaseStruct) *history.Attempt {
+	return &history.Attempt{
+		RunId:      baseStruct.RunId,
+		Login:      baseStruct.Login,
+		ReportName: baseStruct.ReportName,
+		DateFrom:   baseStruct.DateFrom,
+		DateTo:     baseStruct.DateTo,
+		Attempt:    baseStruct.Try,
+	}
+}
+
+// Store attempt in run history
+func addAttempt(attempt *history.Attempt, err error) {
+	if AppHistory == nil || attempt.RunId == 0 {
+		return
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	err = AppHistory.AddAttempt(context.Background(), attempt)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+	}
+}
+
+// Run history command: history [-report name] [-login login] [-status status] [-limit n] [-attempts]
+func runHistory(args []string) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	report := flags.String("report", "", "show runs of report spec")
+	login := flags.String("login", "", "show runs with attempts for login")
+	status := flags.String("status", "", "show runs with status: running, success, partial, failed")
+	limit := flags.Int("limit", 20, "max runs count")
+	attempts := flags.Bool("attempts", false, "show attempts of every run")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	store := history.NewStore(&AppDb, AppConfig.Get().Environment)
+	runs, err := store.Runs(ctx, history.Filter{Report: *report, Login: *login, Status: *status, Limit: *limit})
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	fmt.Printf("%-8s %-16s %-19s %-19s %-8s %6s %6s %6s %7s\n", "RUN", "REPORT", "STARTED", "FINISHED", "STATUS", "JOBS", "OK", "FAILED", "QUALITY")
+	for _, run := range runs {
+		fmt.Printf("%-8d %-16s %-19s %-19s %-8s %6d %6d %6d %7d\n",
+			run.Id, run.Report, run.StartedAt, run.FinishedAt, run.Status, run.JobsTotal, run.JobsSucceeded, run.JobsFailed, run.QualityFailed)
+		if !*attempts {
+			continue
+		}
+		items, err := store.Attempts(ctx, run.Id, *login)
+		if err != nil {
+			Log.Error(err, trace.GetTrace())
+			return err
+		}
+		for _, a := range items {
+			fmt.Printf("    %-19s %-30s %s..%s try %d status %d retryin %d units %d bytes %d %s %s\n",
+				a.CreatedAt, a.Login, a.DateFrom, a.DateTo, a.Attempt, a.StatusCode, a.RetryIn,
+				a.UnitsSpent, a.Bytes, a.FilePath, a.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for working with local cache of downloaded reports.
+// Cache entries are keyed by report name, report name is fingerprint of report request.
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Entry define downloaded report
+type Entry struct {
+	ReportName   string
+	Login        string
+	FilePath     string // Response file of report
+	DownloadedAt time.Time
+}
+
+// Cache define local cache of downloaded reports: entry file per report name in cache directory
+type Cache struct {
+	dir string
+	ttl time.Duration
+}
+
+// Returns new cache, entries are valid for ttl after download
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl}
+}
+
+// Returns entry file path of report name
+func (c *Cache) path(reportName string) string {
+	return filepath.Join(c.dir, reportName+".json")
+}
+
+// Get returns valid entry of report name: entry is not expired and response file exists.
+// Expired entries are removed
+func (c *Cache) Get(reportName string, now time.Time) (*Entry, bool) {
+	content, err := os.ReadFile(c.path(reportName))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Println(err, trace.GetTrace())
+		}
+		return nil, false
+	}
+	entry := &Entry{}
+	err = json.Unmarshal(content, entry)
+	if err != nil || now.Sub(entry.DownloadedAt) >= c.ttl {
+		os.Remove(c.path(reportName))
+		return nil, false
+	}
+	if _, err := os.Stat(entry.FilePath); err != nil {
+		os.Remove(c.path(reportName))
+		return nil, false
+	}
+	return entry, true
+}
+
+// Put store entry of downloaded report
+func (c *Cache) Put(entry Entry) error {
+	err := os.MkdirAll(c.dir, 0777)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	content, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	err = os.WriteFile(c.path(entry.ReportName), content, 0777)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	return nil
+}
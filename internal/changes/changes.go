@@ -0,0 +1,68 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for working with changes state.
+// Changes state stores timestamp of the last Direct Changes service check of every report spec and login.
+package changes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/db"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Datetime format for changes table
+const timeFormat = "2006-01-02 15:04:05"
+
+// State define changes state of report spec login
+type State struct {
+	Report    string `db:"report"`
+	Login     string `db:"login"`
+	CheckedAt string `db:"checked_at"`
+}
+
+// Store define changes state store of environment in database
+type Store struct {
+	db          *db.Db
+	environment string
+}
+
+// Returns new changes state store of environment: production or sandbox
+func NewStore(dbIn *db.Db, environment string) *Store {
+	return &Store{db: dbIn, environment: environment}
+}
+
+// LastCheck returns changes service timestamp of the last check of report spec login.
+// Returns empty string if login has never been checked
+func (s *Store) LastCheck(ctx context.Context, report string, login string) (string, error) {
+	query := "SELECT report, login, checked_at FROM ydloader_changes WHERE environment = ? AND report = ? AND login = ?"
+	state, err := db.QueryOne[State](ctx, s.db, query, []any{s.environment, report, login})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return "", err
+	}
+	return state.CheckedAt, nil
+}
+
+// SetLastCheck store changes service timestamp of the last check of report spec login
+func (s *Store) SetLastCheck(ctx context.Context, report string, login string, timestamp string) error {
+	sql := []string{
+		"INSERT INTO ydloader_changes (environment, report, login, checked_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+		"ON DUPLICATE KEY UPDATE checked_at = VALUES(checked_at), updated_at = VALUES(updated_at)",
+	}
+	params := []any{s.environment, report, login, timestamp, time.Now().UTC().Format(timeFormat)}
+	_, err := s.db.ExecContext(ctx, strings.Join(sql, " "), params)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	return nil
+}
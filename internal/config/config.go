@@ -10,17 +10,304 @@ type Db struct {
 	Database string
 	Username string
 	Password string
+	// DSN parameters
+	Charset      string // Connection charset, e.g. utf8mb4
+	ParseTime    bool   // Parse DATE and DATETIME columns to time.Time
+	Timeout      int    // Dial timeout, seconds
+	ReadTimeout  int    // I/O read timeout, seconds
+	WriteTimeout int    // I/O write timeout, seconds
+	// TLS parameters
+	Tls     string // TLS mode: "", "true", "false", "skip-verify", "preferred" or "custom"
+	TlsCa   string // CA certificate file (custom mode)
+	TlsCert string // Client certificate file (custom mode)
+	TlsKey  string // Client key file (custom mode)
+	// Connection pool parameters
+	MaxOpenConns    int // Maximum number of open connections, 0 - unlimited
+	MaxIdleConns    int // Maximum number of idle connections
+	ConnMaxLifetime int // Maximum connection lifetime, seconds
+	ConnMaxIdleTime int // Maximum connection idle time, seconds
+}
+
+// API environments
+const (
+	EnvironmentProduction = "production"
+	EnvironmentSandbox    = "sandbox"
+)
+
+// Sandbox define Direct sandbox environment: API addresses, token and logins of sandbox
+type Sandbox struct {
+	ReportsUrl string
+	ApiUrl     string
+	Token      string   // Sandbox token, secret reference allowed
+	Logins     []string // Sandbox client logins, logins of database are not used in sandbox
 }
 
 // Http define http configuration
 type Http struct {
 	Timeout    int
 	ReportsUrl string
+	ApiUrl     string // JSON API address of other services, e.g. agencyclients
 	TryCount   int
 }
 
+// History define run history configuration
+type History struct {
+	Enabled bool // Store runs and attempts in database
+}
+
+// Vault define Vault-compatible secret provider configuration
+type Vault struct {
+	Address string // Vault address, e.g. http://127.0.0.1:8200
+	Token   string // Vault token, VAULT_TOKEN environment variable is used if empty
+	Mount   string // KV v2 engine mount, default "secret"
+	Timeout int    // Request timeout, seconds
+}
+
+// Secrets define secrets configuration.
+// Db password and API tokens can be plain values, encrypted values (enc:v1:...)
+// or references to providers: env:NAME, file:path, vault:path#key
+type Secrets struct {
+	KeyEnv  string // Environment variable with tokens encryption key, default YDLOADER_TOKEN_KEY
+	KeyFile string // File with tokens encryption key
+	Vault   Vault
+}
+
+// OAuth define OAuth refresh token flow configuration
+type OAuth struct {
+	Enabled      bool   // Refresh expired tokens with refresh tokens from token source
+	TokenUrl     string // Token endpoint, default https://oauth.yandex.ru/token
+	ClientId     string // Application client id
+	ClientSecret string // Application client secret, secret reference allowed
+	Timeout      int    // Request timeout, seconds
+}
+
+// Report define report spec: what to load and when
+type Report struct {
+	Name     string // Unique report spec name
+	Schedule string // Cron expression for serve mode, e.g. "0 6 * * *", empty - not scheduled
+	Template string // Body template file name, default body.json
+	DateFrom int    // First report date, days relative to today, e.g. -7
+	DateTo   int    // Last report date, days relative to today, e.g. -1
+	Changes  bool   // Load only campaigns and dates changed since the last run, see Direct Changes service
+
+	Goals             []int64  // Goals of report requests, max 10
+	IntegrationGoals  bool     // Use goals of integration (goals section) if goals are not set
+	AttributionModels []string // Attribution models of goals, e.g. LC, FC, AUTO
+
+	Quality Quality // Data quality rules of report
+}
+
+// Quality define data quality rules of report spec, rules are checked after report is parsed
+type Quality struct {
+	NonEmpty  bool     // Report of active login (previous report has rows) must have rows
+	Key       []string // Columns of row key, rows with duplicate keys are errors, e.g. [Date, CampaignId]
+	Sanity    bool     // Clicks must not be greater than impressions, money values must not be negative
+	Deviation int      // Max day-over-day change of daily totals in percent of reports with Date column, 0 - not checked
+	Columns   []string // Columns of daily totals checked by deviation, default Impressions, Clicks, Cost
+	Block     bool     // Report failing rules is not written to tables, job fails
+}
+
+// Enabled returns true if any quality rule is set
+func (q Quality) Enabled() bool {
+	return q.NonEmpty || len(q.Key) > 0 || q.Sanity || q.Deviation > 0
+}
+
+// Default report spec, used if no report specs are configured: yesterday data
+var DefaultReport = Report{Name: "default", Template: "body.json", DateFrom: -1, DateTo: -1}
+
+// Serve define daemon mode configuration
+type Serve struct {
+	CatchUp  bool   // Run missed schedules once after downtime
+	Timezone string // Timezone of schedules, e.g. Europe/Moscow, default Local
+}
+
+// Admin define HTTP admin API configuration, API works in serve mode
+type Admin struct {
+	Enabled bool   // Start admin API
+	Listen  string // Listen address, default 127.0.0.1:8080
+	Token   string // Bearer token of API requests, secret reference allowed
+}
+
+// Metrics define Prometheus metrics endpoint configuration, metrics are served in serve mode
+type Metrics struct {
+	Enabled bool   // Start metrics server
+	Listen  string // Listen address, default 127.0.0.1:9090
+	Path    string // Metrics path, default /metrics
+}
+
+// Log define logging configuration
+type Log struct {
+	Format  string   // text or json
+	Level   string   // Minimum level: debug, info, warn or error
+	Outputs []string // Log outputs: stdout, stderr, file, syslog
+	File    LogFile
+	Syslog  Syslog
+}
+
+// LogFile define log file output with rotation
+type LogFile struct {
+	Path       string // Log file path, default app.log
+	MaxSize    int    // Rotate file when size exceeds megabytes, 0 - no size rotation
+	Daily      bool   // Rotate file when day changes
+	MaxAge     int    // Remove rotated files older than days, 0 - keep
+	MaxBackups int    // Keep max count of rotated files, 0 - keep all
+	Compress   bool   // Compress rotated files with gzip
+}
+
+// Syslog define syslog output
+type Syslog struct {
+	Network string // udp, tcp or unix, empty - local syslog daemon
+	Address string // Syslog server address, empty - local syslog daemon
+	Tag     string // Syslog tag, default ydloader
+}
+
+// Notify define notifications configuration.
+// Notifier is used if it is configured: webhook url, smtp host or telegram token is set
+type Notify struct {
+	Events     []string       // Notified events: run, run_failed, job_failed
+	RateLimit  int            // Max notifications per rate window, 0 - unlimited
+	RateWindow int            // Rate window, seconds
+	Timeout    int            // Send timeout, seconds
+	Run        NotifyTemplate // Run events message template
+	Job        NotifyTemplate // Job events message template
+	Webhook    Webhook
+	Smtp       Smtp
+	Telegram   Telegram
+}
+
+// NotifyTemplate define message template in text/template syntax, empty - default template
+type NotifyTemplate struct {
+	Subject string
+	Text    string
+}
+
+// Webhook define webhook notifier
+type Webhook struct {
+	Url   string // JSON payload is posted to url
+	Token string // Bearer token, secret reference allowed
+}
+
+// Smtp define email notifier
+type Smtp struct {
+	Host     string
+	Port     int
+	Username string
+	Password string // Secret reference allowed
+	From     string
+	To       []string
+}
+
+// Telegram define Telegram bot notifier
+type Telegram struct {
+	Url    string // Bot API address, default https://api.telegram.org
+	Token  string // Bot token, secret reference allowed
+	ChatId string
+}
+
+// Cache define local cache of downloaded reports, identical report requests are not fetched again
+type Cache struct {
+	Enabled bool
+	Dir     string // Cache directory, default ./cache/
+	Ttl     int    // Seconds downloaded report is valid
+}
+
+// Timezones define account timezones of logins, report dates of login are computed in its timezone
+type Timezones struct {
+	Default string            // Timezone of logins with unknown timezone, e.g. Europe/Moscow, default Local
+	Logins  map[string]string // Timezones by lowercase login, e.g. client-1: Asia/Yekaterinburg
+	Db      bool              // Use timezones of ydloader_timezones table for logins missing in config
+	Api     bool              // Detect timezones of other logins by campaigns, detected timezones are stored if db is set
+}
+
+// Goals define goals of integrations, used by report specs with integration goals
+type Goals struct {
+	Integrations map[string][]int64 // Goals by integration id, e.g. "12": [1001, 1002]
+	Db           bool               // Load goals of integrations missing in config from ydloader_goals table
+}
+
+// Money define money values of reports
+type Money struct {
+	Micros   bool              // Request money values in micros, returnMoneyInMicros header
+	Table    bool              // Write money table: decimal values, VAT-inclusive and VAT-exclusive values, currency
+	Currency string            // Reporting currency of money table, e.g. RUB, empty - account currency
+	Rates    map[string]string // Rates of account currencies to reporting currency, e.g. USD: "92.5"
+}
+
+// Dimensions define dimension sync: campaigns, ad groups, ads and keywords of logins
+type Dimensions struct {
+	Entities    []string // Synced dimensions: campaigns, adgroups, ads, keywords
+	Concurrency int      // Max logins synced at the same time
+	PageLimit   int      // Items per page of get requests, max 10000
+}
+
 // Config define application configuration
 type Config struct {
-	Db   Db
-	Http Http
+	Environment string // production or sandbox
+	Sandbox     Sandbox
+	Db          Db
+	Http        Http
+	History     History
+	Secrets     Secrets
+	OAuth       OAuth
+	Serve       Serve
+	Admin       Admin
+	Metrics     Metrics
+	Log         Log
+	Notify      Notify
+	Cache       Cache
+	Timezones   Timezones
+	Goals       Goals
+	Money       Money
+	Dimensions  Dimensions
+	Reports     []Report
+}
+
+// IsSandbox returns true if API calls go to Direct sandbox
+func (c Config) IsSandbox() bool {
+	return c.Environment == EnvironmentSandbox
+}
+
+// ReportsUrl returns reports service address of environment
+func (c Config) ReportsUrl() string {
+	if c.IsSandbox() {
+		return c.Sandbox.ReportsUrl
+	}
+	return c.Http.ReportsUrl
+}
+
+// ApiUrl returns JSON API address of environment
+func (c Config) ApiUrl() string {
+	if c.IsSandbox() {
+		return c.Sandbox.ApiUrl
+	}
+	return c.Http.ApiUrl
+}
+
+// ReportSpecs returns configured report specs with defaults or default report spec
+func (c Config) ReportSpecs() []Report {
+	if len(c.Reports) == 0 {
+		return []Report{DefaultReport}
+	}
+	specs := make([]Report, 0, len(c.Reports))
+	for _, spec := range c.Reports {
+		if spec.Template == "" {
+			spec.Template = DefaultReport.Template
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// ReportSpec returns report spec by name, first report spec if name is empty
+func (c Config) ReportSpec(name string) (Report, bool) {
+	specs := c.ReportSpecs()
+	if name == "" {
+		return specs[0], true
+	}
+	for _, spec := range specs {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return Report{}, false
 }
@@ -0,0 +1,24 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package config
+
+import "sync/atomic"
+
+// Holder holds current configuration, safe for concurrent use.
+// Configuration is replaced as a whole on reload
+type Holder struct {
+	current atomic.Pointer[Config]
+}
+
+// Get returns current configuration
+func (h *Holder) Get() Config {
+	if c := h.current.Load(); c != nil {
+		return *c
+	}
+	return Config{}
+}
+
+// Set replace current configuration
+func (h *Holder) Set(c Config) {
+	h.current.Store(&c)
+}
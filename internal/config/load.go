@@ -0,0 +1,205 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+	"github.com/spf13/viper"
+)
+
+// Prefix of environment variables overriding configuration, e.g. YDLOADER_DB_PASSWORD
+const EnvPrefix = "YDLOADER"
+
+// Environment variable with configuration file path
+const EnvConfigFile = EnvPrefix + "_CONFIG"
+
+// Config file name (without extension) searched in config paths
+const configName = "config"
+
+// Config file search paths in priority order
+var configPaths = []string{"./config/", ".", "$HOME/.config/ydloader", "/etc/ydloader"}
+
+// Default values of every configuration field
+var defaults = map[string]any{
+	"db.host":            "127.0.0.1",
+	"db.port":            3306,
+	"db.database":        "",
+	"db.username":        "",
+	"db.password":        "",
+	"db.charset":         "utf8mb4",
+	"db.parsetime":       true,
+	"db.timeout":         10,
+	"db.readtimeout":     30,
+	"db.writetimeout":    30,
+	"db.tls":             "",
+	"db.tlsca":           "",
+	"db.tlscert":         "",
+	"db.tlskey":          "",
+	"db.maxopenconns":    10,
+	"db.maxidleconns":    5,
+	"db.connmaxlifetime": 300,
+	"db.connmaxidletime": 60,
+
+	"http.timeout":    180,
+	"http.reportsurl": "https://api.direct.yandex.com/json/v5/reports",
+	"http.apiurl":     "https://api.direct.yandex.com/json/v5/",
+
+	"environment":        "production",
+	"sandbox.reportsurl": "https://api-sandbox.direct.yandex.com/json/v5/reports",
+	"sandbox.apiurl":     "https://api-sandbox.direct.yandex.com/json/v5/",
+	"sandbox.token":      "",
+	"sandbox.logins":     []string{},
+	"http.trycount":      50,
+
+	"history.enabled": false,
+
+	"secrets.keyenv":        "YDLOADER_TOKEN_KEY",
+	"secrets.keyfile":       "",
+	"secrets.vault.address": "",
+	"secrets.vault.token":   "",
+	"secrets.vault.mount":   "secret",
+	"secrets.vault.timeout": 10,
+
+	"oauth.enabled":      false,
+	"oauth.tokenurl":     "https://oauth.yandex.ru/token",
+	"oauth.clientid":     "",
+	"oauth.clientsecret": "",
+	"oauth.timeout":      30,
+
+	"serve.catchup":  true,
+	"serve.timezone": "Local",
+
+	"admin.enabled": false,
+	"admin.listen":  "127.0.0.1:8080",
+	"admin.token":   "",
+
+	"metrics.enabled": false,
+	"metrics.listen":  "127.0.0.1:9090",
+	"metrics.path":    "/metrics",
+
+	"log.format":          "text",
+	"log.level":           "info",
+	"log.outputs":         []string{"file", "stdout"},
+	"log.file.path":       "app.log",
+	"log.file.maxsize":    100,
+	"log.file.daily":      false,
+	"log.file.maxage":     30,
+	"log.file.maxbackups": 10,
+	"log.file.compress":   true,
+	"log.syslog.network":  "",
+	"log.syslog.address":  "",
+	"log.syslog.tag":      "ydloader",
+
+	"notify.events":          []string{"run_failed", "job_failed"},
+	"notify.ratelimit":       10,
+	"notify.ratewindow":      3600,
+	"notify.timeout":         30,
+	"notify.run.subject":     "",
+	"notify.run.text":        "",
+	"notify.job.subject":     "",
+	"notify.job.text":        "",
+	"notify.webhook.url":     "",
+	"notify.webhook.token":   "",
+	"notify.smtp.host":       "",
+	"notify.smtp.port":       587,
+	"notify.smtp.username":   "",
+	"notify.smtp.password":   "",
+	"notify.smtp.from":       "",
+	"notify.smtp.to":         []string{},
+	"notify.telegram.url":    "https://api.telegram.org",
+	"notify.telegram.token":  "",
+	"notify.telegram.chatid": "",
+
+	"cache.enabled": false,
+	"cache.dir":     "./cache/",
+	"cache.ttl":     3600,
+
+	"timezones.default": "Local",
+	"timezones.logins":  map[string]string{},
+	"timezones.db":      false,
+	"timezones.api":     false,
+
+	"goals.integrations": map[string][]int64{},
+	"goals.db":           false,
+
+	"money.micros":   false,
+	"money.table":    false,
+	"money.currency": "",
+	"money.rates":    map[string]string{},
+
+	"dimensions.entities":    []string{"campaigns", "adgroups", "ads", "keywords"},
+	"dimensions.concurrency": 4,
+	"dimensions.pagelimit":   10000,
+}
+
+// Loader reads application configuration
+type Loader struct {
+	viper *viper.Viper
+}
+
+// Returns new configuration loader.
+// If path is empty, config.yml is searched in ./config/, ., $HOME/.config/ydloader and /etc/ydloader
+func NewLoader(path string) *Loader {
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	v.SetConfigType("yaml")
+	if path != "" {
+		v.SetConfigFile(path)
+	} else {
+		v.SetConfigName(configName)
+		for _, configPath := range configPaths {
+			v.AddConfigPath(configPath)
+		}
+	}
+	return &Loader{viper: v}
+}
+
+// Viper returns viper instance of the loader
+func (l *Loader) Viper() *viper.Viper {
+	return l.viper
+}
+
+// File returns used configuration file, empty if file is not found
+func (l *Loader) File() string {
+	return l.viper.ConfigFileUsed()
+}
+
+// Load read configuration file and environment overrides.
+// Configuration without file is allowed if config path is not set explicitly
+func (l *Loader) Load() (Config, error) {
+	var appConfig Config
+	err := l.viper.ReadInConfig()
+	if err != nil {
+		notFound := viper.ConfigFileNotFoundError{}
+		if !errors.As(err, &notFound) {
+			err = fmt.Errorf("config file: %w", err)
+			fmt.Println(err, trace.GetTrace())
+			return appConfig, err
+		}
+	}
+	err = l.viper.Unmarshal(&appConfig)
+	if err != nil {
+		err = fmt.Errorf("unable to decode config: %w", err)
+		fmt.Println(err, trace.GetTrace())
+		return appConfig, err
+	}
+	return appConfig, nil
+}
+
+// Load read and validate configuration
+func Load(path string) (Config, error) {
+	appConfig, err := NewLoader(path).Load()
+	if err != nil {
+		return appConfig, err
+	}
+	return appConfig, appConfig.Validate()
+}
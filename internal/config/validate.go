@@ -0,0 +1,304 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/cron"
+	"github.com/AlekseiGrigorev/ydloader/internal/direct"
+	"github.com/AlekseiGrigorev/ydloader/internal/logger"
+	"github.com/AlekseiGrigorev/ydloader/internal/money"
+	"github.com/AlekseiGrigorev/ydloader/internal/notify"
+	"github.com/AlekseiGrigorev/ydloader/internal/report"
+)
+
+// Allowed db TLS modes
+var tlsModes = []string{"", "true", "false", "skip-verify", "preferred", "custom"}
+
+// Allowed log outputs
+var logOutputs = []string{logger.OutputStdout, logger.OutputStderr, logger.OutputFile, logger.OutputSyslog}
+
+// Allowed notification events
+var notifyEvents = []string{notify.EventRun, notify.EventRunFailed, notify.EventJobFailed}
+
+// Allowed syslog networks
+var syslogNetworks = []string{"", "udp", "tcp", "unix"}
+
+// Secret reference prefixes, references are not masked
+var secretRefPrefixes = []string{"env:", "file:", "vault:"}
+
+// Validation errors collector
+type validator struct {
+	errs []error
+}
+
+// Add validation error for config key
+func (v *validator) add(key string, format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+// Check value is set
+func (v *validator) required(key string, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(key, "must be set")
+	}
+}
+
+// Check value is positive
+func (v *validator) positive(key string, value int) {
+	if value <= 0 {
+		v.add(key, "must be greater than 0, got %d", value)
+	}
+}
+
+// Check value is not negative
+func (v *validator) notNegative(key string, value int) {
+	if value < 0 {
+		v.add(key, "must not be negative, got %d", value)
+	}
+}
+
+// Check value is absolute http(s) url
+func (v *validator) url(key string, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(key, "must be absolute http(s) url, got %q", value)
+	}
+}
+
+// Validate returns error with all configuration problems, nil if configuration is valid
+func (c Config) Validate() error {
+	v := &validator{}
+
+	v.required("db.host", c.Db.Host)
+	v.required("db.database", c.Db.Database)
+	v.required("db.username", c.Db.Username)
+	if c.Db.Port < 0 || c.Db.Port > 65535 {
+		v.add("db.port", "must be between 0 and 65535, got %d", c.Db.Port)
+	}
+	if !slices.Contains(tlsModes, c.Db.Tls) {
+		v.add("db.tls", "must be one of %s, got %q", strings.Join(tlsModes[1:], ", "), c.Db.Tls)
+	}
+	if c.Db.Tls == "custom" && c.Db.TlsCa == "" && c.Db.TlsCert == "" {
+		v.add("db.tls", "custom mode requires db.tlsca or db.tlscert")
+	}
+	if (c.Db.TlsCert == "") != (c.Db.TlsKey == "") {
+		v.add("db.tlscert", "db.tlscert and db.tlskey must be set together")
+	}
+	v.notNegative("db.timeout", c.Db.Timeout)
+	v.notNegative("db.readtimeout", c.Db.ReadTimeout)
+	v.notNegative("db.writetimeout", c.Db.WriteTimeout)
+	v.notNegative("db.maxopenconns", c.Db.MaxOpenConns)
+	v.notNegative("db.maxidleconns", c.Db.MaxIdleConns)
+	v.notNegative("db.connmaxlifetime", c.Db.ConnMaxLifetime)
+	v.notNegative("db.connmaxidletime", c.Db.ConnMaxIdleTime)
+
+	v.positive("http.timeout", c.Http.Timeout)
+	v.positive("http.trycount", c.Http.TryCount)
+	v.url("http.reportsurl", c.Http.ReportsUrl)
+	v.url("http.apiurl", c.Http.ApiUrl)
+
+	switch c.Environment {
+	case EnvironmentProduction:
+	case EnvironmentSandbox:
+		v.url("sandbox.reportsurl", c.Sandbox.ReportsUrl)
+		v.url("sandbox.apiurl", c.Sandbox.ApiUrl)
+		v.required("sandbox.token", c.Sandbox.Token)
+		if len(c.Sandbox.Logins) == 0 {
+			v.add("sandbox.logins", "must be set")
+		}
+	default:
+		v.add("environment", "must be production or sandbox, got %q", c.Environment)
+	}
+
+	if c.Secrets.Vault.Address != "" {
+		v.url("secrets.vault.address", c.Secrets.Vault.Address)
+		v.positive("secrets.vault.timeout", c.Secrets.Vault.Timeout)
+	}
+
+	if c.OAuth.Enabled {
+		v.url("oauth.tokenurl", c.OAuth.TokenUrl)
+		v.required("oauth.clientid", c.OAuth.ClientId)
+		v.required("oauth.clientsecret", c.OAuth.ClientSecret)
+		v.positive("oauth.timeout", c.OAuth.Timeout)
+	}
+
+	if _, err := time.LoadLocation(c.Serve.Timezone); err != nil {
+		v.add("serve.timezone", "unknown timezone %q", c.Serve.Timezone)
+	}
+	if c.Admin.Enabled {
+		v.required("admin.listen", c.Admin.Listen)
+		v.required("admin.token", c.Admin.Token)
+	}
+	if c.Log.Format != logger.FormatText && c.Log.Format != logger.FormatJson {
+		v.add("log.format", "must be text or json, got %q", c.Log.Format)
+	}
+	if _, err := logger.ParseLevel(c.Log.Level); err != nil {
+		v.add("log.level", "must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+	if len(c.Log.Outputs) == 0 {
+		v.add("log.outputs", "must be set")
+	}
+	for _, output := range c.Log.Outputs {
+		if !slices.Contains(logOutputs, output) {
+			v.add("log.outputs", "must be %s, got %q", strings.Join(logOutputs, ", "), output)
+		}
+	}
+	if slices.Contains(c.Log.Outputs, logger.OutputFile) {
+		v.required("log.file.path", c.Log.File.Path)
+		v.notNegative("log.file.maxsize", c.Log.File.MaxSize)
+		v.notNegative("log.file.maxage", c.Log.File.MaxAge)
+		v.notNegative("log.file.maxbackups", c.Log.File.MaxBackups)
+	}
+	if slices.Contains(c.Log.Outputs, logger.OutputSyslog) && !slices.Contains(syslogNetworks, c.Log.Syslog.Network) {
+		v.add("log.syslog.network", "must be empty, udp, tcp or unix, got %q", c.Log.Syslog.Network)
+	}
+	for _, event := range c.Notify.Events {
+		if !slices.Contains(notifyEvents, event) {
+			v.add("notify.events", "must be %s, got %q", strings.Join(notifyEvents, ", "), event)
+		}
+	}
+	v.notNegative("notify.ratelimit", c.Notify.RateLimit)
+	if c.Notify.RateLimit > 0 {
+		v.positive("notify.ratewindow", c.Notify.RateWindow)
+	}
+	v.positive("notify.timeout", c.Notify.Timeout)
+	for key, tmpl := range map[string]NotifyTemplate{"notify.run": c.Notify.Run, "notify.job": c.Notify.Job} {
+		if err := notify.ValidateTemplate(notify.Template(tmpl)); err != nil {
+			v.add(key, "invalid template: %s", err)
+		}
+	}
+	if c.Notify.Webhook.Url != "" {
+		v.url("notify.webhook.url", c.Notify.Webhook.Url)
+	}
+	if c.Notify.Smtp.Host != "" {
+		v.positive("notify.smtp.port", c.Notify.Smtp.Port)
+		v.required("notify.smtp.from", c.Notify.Smtp.From)
+		if len(c.Notify.Smtp.To) == 0 {
+			v.add("notify.smtp.to", "must be set")
+		}
+	}
+	if c.Notify.Telegram.Token != "" {
+		v.url("notify.telegram.url", c.Notify.Telegram.Url)
+		v.required("notify.telegram.chatid", c.Notify.Telegram.ChatId)
+	}
+
+	if c.Metrics.Enabled {
+		v.required("metrics.listen", c.Metrics.Listen)
+		if !strings.HasPrefix(c.Metrics.Path, "/") {
+			v.add("metrics.path", "must start with /, got %q", c.Metrics.Path)
+		}
+	}
+
+	for _, entity := range c.Dimensions.Entities {
+		if !slices.Contains(direct.DimensionNames, entity) {
+			v.add("dimensions.entities", "must be %s, got %q", strings.Join(direct.DimensionNames, ", "), entity)
+		}
+	}
+	v.positive("dimensions.concurrency", c.Dimensions.Concurrency)
+	if c.Dimensions.PageLimit <= 0 || c.Dimensions.PageLimit > direct.DefaultPageLimit {
+		v.add("dimensions.pagelimit", "must be between 1 and %d, got %d", direct.DefaultPageLimit, c.Dimensions.PageLimit)
+	}
+
+	if c.Cache.Enabled {
+		v.required("cache.dir", c.Cache.Dir)
+		v.positive("cache.ttl", c.Cache.Ttl)
+	}
+	if _, err := time.LoadLocation(c.Timezones.Default); err != nil {
+		v.add("timezones.default", "unknown timezone %q", c.Timezones.Default)
+	}
+	for login, timezone := range c.Timezones.Logins {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			v.add("timezones.logins."+login, "unknown timezone %q", timezone)
+		}
+	}
+	for id, goals := range c.Goals.Integrations {
+		if _, err := strconv.Atoi(id); err != nil {
+			v.add("goals.integrations", "key must be integration id, got %q", id)
+		}
+		if len(goals) > 10 {
+			v.add("goals.integrations."+id, "max 10 goals, got %d", len(goals))
+		}
+	}
+
+	for currency, value := range c.Money.Rates {
+		if rate, err := money.Parse(value); err != nil || rate.Micros() <= 0 {
+			v.add("money.rates."+currency, "must be positive decimal, got %q", value)
+		}
+	}
+
+	names := map[string]bool{}
+	for i, spec := range c.Reports {
+		key := fmt.Sprintf("reports[%d]", i)
+		if spec.Name == "" {
+			v.add(key+".name", "must be set")
+		} else if names[spec.Name] {
+			v.add(key+".name", "duplicate report name %q", spec.Name)
+		}
+		names[spec.Name] = true
+		if spec.Schedule != "" {
+			if err := cron.Validate(spec.Schedule); err != nil {
+				v.add(key+".schedule", "%s", err)
+			}
+		}
+		if spec.DateFrom > spec.DateTo {
+			v.add(key+".datefrom", "must not be after dateto, got %d > %d", spec.DateFrom, spec.DateTo)
+		}
+		if spec.DateTo > 0 {
+			v.add(key+".dateto", "must not be in the future, got %d", spec.DateTo)
+		}
+		if len(spec.Goals) > 10 {
+			v.add(key+".goals", "max 10 goals, got %d", len(spec.Goals))
+		}
+		if len(spec.AttributionModels) > 0 && len(spec.Goals) == 0 && !spec.IntegrationGoals {
+			v.add(key+".attributionmodels", "require goals or integrationgoals")
+		}
+		for _, model := range spec.AttributionModels {
+			if !slices.Contains(report.AttributionModels, model) {
+				v.add(key+".attributionmodels", "must be %s, got %q", strings.Join(report.AttributionModels, ", "), model)
+			}
+		}
+		v.notNegative(key+".quality.deviation", spec.Quality.Deviation)
+		if len(spec.Quality.Columns) > 0 && spec.Quality.Deviation == 0 {
+			v.add(key+".quality.columns", "require quality.deviation")
+		}
+		if spec.Quality.Block && !spec.Quality.Enabled() {
+			v.add(key+".quality.block", "require quality rules")
+		}
+	}
+
+	return errors.Join(v.errs...)
+}
+
+// Returns masked secret value, secret references are kept as is
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	for _, prefix := range secretRefPrefixes {
+		if strings.HasPrefix(value, prefix) {
+			return value
+		}
+	}
+	return "******"
+}
+
+// Masked returns copy of configuration with masked secrets, safe for print
+func (c Config) Masked() Config {
+	c.Db.Password = maskSecret(c.Db.Password)
+	c.Sandbox.Token = maskSecret(c.Sandbox.Token)
+	c.Secrets.Vault.Token = maskSecret(c.Secrets.Vault.Token)
+	c.OAuth.ClientSecret = maskSecret(c.OAuth.ClientSecret)
+	c.Admin.Token = maskSecret(c.Admin.Token)
+	c.Notify.Webhook.Token = maskSecret(c.Notify.Webhook.Token)
+	c.Notify.Smtp.Password = maskSecret(c.Notify.Smtp.Password)
+	c.Notify.Telegram.Token = maskSecret(c.Notify.Telegram.Token)
+	return c
+}
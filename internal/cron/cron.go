@@ -0,0 +1,225 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for cron-style schedules.
+// Standard 5 fields expressions are supported: minute hour day-of-month month day-of-week,
+// with lists, ranges, steps, month and day names and @yearly, @monthly, @weekly, @daily, @hourly macros.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Max period to search next activation time
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// Macros of expressions
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Field bounds and names
+type field struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+// Schedule define parsed cron expression
+type Schedule struct {
+	expr    string
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	anyHour bool // Hour covers all hours
+	anyDom  bool // Day of month covers all days, like "*" or "*/1"
+	anyDow  bool // Day of week covers all days, like "*" or "0-6"
+}
+
+// Parse returns schedule for cron expression
+func Parse(expr string) (*Schedule, error) {
+	text := strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(text)]; ok {
+		text = macro
+	}
+	parts := strings.Fields(text)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron %q: expected %d fields, got %d", expr, len(fields), len(parts))
+	}
+	bits := make([]uint64, len(fields))
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %s: %w", expr, fields[i].name, err)
+		}
+		bits[i] = b
+	}
+	// Sunday can be 0 or 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return &Schedule{
+		expr:    expr,
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		anyHour: bits[1] == rangeBits(0, 23),
+		anyDom:  bits[2]&rangeBits(1, 31) == rangeBits(1, 31),
+		anyDow:  bits[4]&rangeBits(0, 6) == rangeBits(0, 6),
+	}, nil
+}
+
+// Returns bit set of values from min to max
+func rangeBits(min int, max int) uint64 {
+	return (1<<uint(max+1) - 1) &^ (1<<uint(min) - 1)
+}
+
+// Parse one field to bit set of allowed values
+func parseField(text string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(text, ",") {
+		rangeText, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepText)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepText)
+			}
+		}
+		from, to := f.min, f.max
+		if rangeText != "*" {
+			fromText, toText, isRange := strings.Cut(rangeText, "-")
+			var err error
+			from, err = parseValue(fromText, f)
+			if err != nil {
+				return 0, err
+			}
+			to = from
+			if isRange {
+				to, err = parseValue(toText, f)
+				if err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				to = f.max
+			}
+			if from > to {
+				return 0, fmt.Errorf("invalid range %q", rangeText)
+			}
+		}
+		for v := from; v <= to; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Parse one value: number or name
+func parseValue(text string, f field) (int, error) {
+	if v, ok := f.names[strings.ToLower(text)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", text)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String returns source expression
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Returns true if day matches day of month and day of week fields.
+// If both fields are restricted, day matches any of them
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.anyDom || s.anyDow {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Returns true if wall clock time of t already occurred before t, when clocks were turned back
+func repeated(t time.Time) bool {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return false
+	}
+	_, offset := t.Zone()
+	_, prevOffset := start.Add(-time.Second).Zone()
+	return prevOffset > offset && t.Before(start.Add(time.Duration(prevOffset-offset)*time.Second))
+}
+
+// Next returns first activation time after t, in t location.
+// Times skipped when clocks are turned forward are not activated. Times repeated when clocks
+// are turned back are activated once, except schedules running every hour.
+// Returns zero time if there is no activation time in 5 years
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxSearch)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		// Next hour is counted in minutes, so hour repeated when clocks are turned back is not skipped
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 || (!s.anyHour && repeated(t)) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// Validate returns error if expression is invalid
+func Validate(expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		return errors.New("cron expression is empty")
+	}
+	_, err := Parse(expr)
+	return err
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		expr  string
+		valid bool
+	}{
+		{"every minute", "* * * * *", true},
+		{"lists, ranges and steps", "0,30 9-17/2 1-15 */3 1-5", true},
+		{"names", "0 12 * jan-mar mon,fri", true},
+		{"macro", "@daily", true},
+		{"sunday as 7", "0 0 * * 7", true},
+		{"empty", "", false},
+		{"four fields", "* * * *", false},
+		{"minute out of range", "60 * * * *", false},
+		{"hour out of range", "* 24 * * *", false},
+		{"day of month out of range", "* * 0 * *", false},
+		{"month out of range", "* * * 13 *", false},
+		{"day of week out of range", "* * * * 8", false},
+		{"reversed range", "5-1 * * * *", false},
+		{"zero step", "*/0 * * * *", false},
+		{"unknown name", "* * * foo *", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.expr)
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate(%q) = %v, want valid %v", tt.expr, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		expr string
+		loc  *time.Location
+		from string
+		want string // Empty if there is no activation time
+	}{
+		{"step", "*/15 * * * *", time.UTC, "2024-05-01T10:07:00Z", "2024-05-01T10:15:00Z"},
+		{"seconds are truncated", "15 10 * * *", time.UTC, "2024-05-01T10:14:30Z", "2024-05-01T10:15:00Z"},
+		{"activation time is excluded", "15 10 * * *", time.UTC, "2024-05-01T10:15:00Z", "2024-05-02T10:15:00Z"},
+		{"range with step", "0 9-17/4 * * *", time.UTC, "2024-05-01T10:00:00Z", "2024-05-01T13:00:00Z"},
+		{"list of days", "0 0 1,15 * *", time.UTC, "2024-05-02T00:00:00Z", "2024-05-15T00:00:00Z"},
+		{"month and day names", "0 12 * jan-mar mon", time.UTC, "2024-05-01T00:00:00Z", "2025-01-06T12:00:00Z"},
+		{"weekly macro", "@weekly", time.UTC, "2024-05-01T00:00:00Z", "2024-05-05T00:00:00Z"},
+		{"sunday as 7", "0 0 * * 7", time.UTC, "2024-05-01T00:00:00Z", "2024-05-05T00:00:00Z"},
+		{"day of month or day of week", "0 0 13 * fri", time.UTC, "2024-05-01T00:00:00Z", "2024-05-03T00:00:00Z"},
+		{"day of week with step covers all days", "0 0 13 * */1", time.UTC, "2024-05-01T00:00:00Z", "2024-05-13T00:00:00Z"},
+		{"day of month with step covers all days", "0 0 */1 * fri", time.UTC, "2024-05-01T00:00:00Z", "2024-05-03T00:00:00Z"},
+		{"no such day", "0 0 31 2 *", time.UTC, "2024-05-01T00:00:00Z", ""},
+		{"spring forward, skipped time", "30 2 * * *", newYork, "2024-03-10T00:00:00-05:00", "2024-03-11T02:30:00-04:00"},
+		{"spring forward, hour after gap", "30 3 * * *", newYork, "2024-03-10T00:00:00-05:00", "2024-03-10T03:30:00-04:00"},
+		{"fall back, first occurrence", "30 1 * * *", newYork, "2024-11-03T00:00:00-04:00", "2024-11-03T01:30:00-04:00"},
+		{"fall back, repeated time is skipped", "30 1 * * *", newYork, "2024-11-03T01:30:00-04:00", "2024-11-04T01:30:00-05:00"},
+		{"fall back, every hour schedule", "30 * * * *", newYork, "2024-11-03T01:30:00-04:00", "2024-11-03T01:30:00-05:00"},
+		{"fall back, first occurrence after hour step", "30 2 * * *", berlin, "2024-10-27T00:00:00+02:00", "2024-10-27T02:30:00+02:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			from, err := time.Parse(time.RFC3339, tt.from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next := schedule.Next(from.In(tt.loc))
+			got := ""
+			if !next.IsZero() {
+				got = next.Format(time.RFC3339)
+				if next.Location() != tt.loc {
+					t.Errorf("Next(%s) location = %s, want %s", tt.from, next.Location(), tt.loc)
+				}
+			}
+			if got != tt.want {
+				t.Errorf("%q Next(%s) = %s, want %s", tt.expr, tt.from, got, tt.want)
+			}
+		})
+	}
+}
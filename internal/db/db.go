@@ -4,14 +4,25 @@
 package db
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
 	"strconv"
+	"sync"
+	"time"
 
+	"github.com/AlekseiGrigorev/ydloader/internal/config"
 	"github.com/AlekseiGrigorev/ydloader/internal/trace"
 	"github.com/go-sql-driver/mysql"
 )
 
+// Name of custom TLS config registered in mysql driver
+const tlsConfigName = "ydloader"
+
 // RowModel define interface for model, created from on row of selected data
 type RowModel interface {
 	GetNewModel() RowModel
@@ -21,31 +32,94 @@ type RowModel interface {
 // Db define database config and connection
 type Db struct {
 	config mysql.Config
+	pool   config.Db
 	db     *sql.DB
+	mu     sync.Mutex
 }
 
-// Init database connection
-func (dbIn *Db) Init(user string, passwd string, addr string, port int, dbName string) {
-	if port != 0 {
-		addr += ":" + strconv.Itoa(port)
+// Init database config from application db config.
+// Connection opens lazily on first query.
+func (dbIn *Db) Init(conf config.Db) error {
+	addr := conf.Host
+	if conf.Port != 0 {
+		addr += ":" + strconv.Itoa(conf.Port)
 	}
 
 	dbIn.config = mysql.Config{
-		User:                 user,
-		Passwd:               passwd,
+		User:                 conf.Username,
+		Passwd:               conf.Password,
 		Net:                  "tcp",
 		Addr:                 addr,
-		DBName:               dbName,
+		DBName:               conf.Database,
+		Loc:                  time.UTC,
+		ParseTime:            conf.ParseTime,
+		Timeout:              time.Duration(conf.Timeout) * time.Second,
+		ReadTimeout:          time.Duration(conf.ReadTimeout) * time.Second,
+		WriteTimeout:         time.Duration(conf.WriteTimeout) * time.Second,
 		AllowNativePasswords: true,
+		CheckConnLiveness:    true,
+	}
+	if conf.Charset != "" {
+		dbIn.config.Params = map[string]string{"charset": conf.Charset}
+	}
+	tlsConfig, err := tlsConfigFor(conf)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
 	}
+	dbIn.config.TLSConfig = tlsConfig
+	dbIn.pool = conf
+	return nil
 }
 
-// Open database connection
-func (dbIn *Db) open() error {
+// Returns TLS config name for DSN, registers custom TLS config if needed
+func tlsConfigFor(conf config.Db) (string, error) {
+	if conf.Tls != "custom" {
+		return conf.Tls, nil
+	}
+	tlsConfig := &tls.Config{ServerName: conf.Host}
+	if conf.TlsCa != "" {
+		pem, err := os.ReadFile(conf.TlsCa)
+		if err != nil {
+			return "", err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return "", errors.New("failed to append CA certificate " + conf.TlsCa)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if conf.TlsCert != "" || conf.TlsKey != "" {
+		cert, err := tls.LoadX509KeyPair(conf.TlsCert, conf.TlsKey)
+		if err != nil {
+			return "", err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	err := mysql.RegisterTLSConfig(tlsConfigName, tlsConfig)
+	if err != nil {
+		return "", err
+	}
+	return tlsConfigName, nil
+}
+
+// Open database connection pool and check it once
+func (dbIn *Db) open(ctx context.Context) error {
 	var dsn = dbIn.config.FormatDSN()
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
-		db = nil
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	db.SetMaxOpenConns(dbIn.pool.MaxOpenConns)
+	if dbIn.pool.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(dbIn.pool.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(time.Duration(dbIn.pool.ConnMaxLifetime) * time.Second)
+	db.SetConnMaxIdleTime(time.Duration(dbIn.pool.ConnMaxIdleTime) * time.Second)
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
 		fmt.Println(err, trace.GetTrace())
 		return err
 	}
@@ -53,16 +127,14 @@ func (dbIn *Db) open() error {
 	return nil
 }
 
-// Connect to database. Open connection if needed
-func (dbIn *Db) connect() error {
-	if dbIn.db == nil {
-		err := dbIn.open()
-		if err != nil {
-			fmt.Println(err, trace.GetTrace())
-			return err
-		}
+// Connect to database. Open connection pool if needed
+func (dbIn *Db) connect(ctx context.Context) error {
+	dbIn.mu.Lock()
+	defer dbIn.mu.Unlock()
+	if dbIn.db != nil {
+		return nil
 	}
-	err := dbIn.db.Ping()
+	err := dbIn.open(ctx)
 	if err != nil {
 		fmt.Println(err, trace.GetTrace())
 		return err
@@ -70,16 +142,34 @@ func (dbIn *Db) connect() error {
 	return nil
 }
 
+// Close database connection pool
+func (dbIn *Db) Close() error {
+	dbIn.mu.Lock()
+	defer dbIn.mu.Unlock()
+	if dbIn.db == nil {
+		return nil
+	}
+	err := dbIn.db.Close()
+	dbIn.db = nil
+	return err
+}
+
 // QueryRow query one row data from database
 // Returns RowModel
 func (dbIn *Db) QueryRow(sql string, params []any, model RowModel) (RowModel, error) {
-	err := dbIn.connect()
+	return dbIn.QueryRowContext(context.Background(), sql, params, model)
+}
+
+// QueryRowContext query one row data from database with context
+// Returns RowModel
+func (dbIn *Db) QueryRowContext(ctx context.Context, sql string, params []any, model RowModel) (RowModel, error) {
+	err := dbIn.connect(ctx)
 	if err != nil {
 		fmt.Println(err, trace.GetTrace())
 		return nil, err
 	}
 	newModel := model.GetNewModel()
-	err = dbIn.db.QueryRow(sql, params...).Scan(newModel.GetColumnPointers()...)
+	err = dbIn.db.QueryRowContext(ctx, sql, params...).Scan(newModel.GetColumnPointers()...)
 	if err != nil {
 		fmt.Println(err, trace.GetTrace())
 		return nil, err
@@ -91,12 +181,13 @@ func (dbIn *Db) QueryRow(sql string, params []any, model RowModel) (RowModel, er
 // Query query rows data from database
 // Returns slice of RowModels
 func (dbIn *Db) Query(sql string, params []any, model RowModel) ([]RowModel, error) {
-	err := dbIn.connect()
-	if err != nil {
-		fmt.Println(err, trace.GetTrace())
-		return nil, err
-	}
-	rows, err := dbIn.db.Query(sql, params...)
+	return dbIn.QueryContext(context.Background(), sql, params, model)
+}
+
+// QueryContext query rows data from database with context
+// Returns slice of RowModels
+func (dbIn *Db) QueryContext(ctx context.Context, sql string, params []any, model RowModel) ([]RowModel, error) {
+	rows, err := dbIn.QueryRowsContext(ctx, sql, params)
 	if err != nil {
 		fmt.Println(err, trace.GetTrace())
 		return nil, err
@@ -111,6 +202,77 @@ func (dbIn *Db) Query(sql string, params []any, model RowModel) ([]RowModel, err
 		}
 		retRows = append(retRows, newModel)
 	}
+	if err := rows.Err(); err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
 
 	return retRows, nil
 }
+
+// QueryRowsContext query rows from database with context.
+// Rows must be closed by caller
+func (dbIn *Db) QueryRowsContext(ctx context.Context, sql string, params []any) (*sql.Rows, error) {
+	err := dbIn.connect(ctx)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	rows, err := dbIn.db.QueryContext(ctx, sql, params...)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ExecContext execute statement without returning rows
+func (dbIn *Db) ExecContext(ctx context.Context, sql string, params []any) (sql.Result, error) {
+	err := dbIn.connect(ctx)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	res, err := dbIn.db.ExecContext(ctx, sql, params...)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	return res, nil
+}
+
+// TryLock acquire named database lock without waiting.
+// Lock is held by dedicated connection until unlock is called.
+// Returns false if lock is held by other session
+func (dbIn *Db) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	err := dbIn.connect(ctx)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, false, err
+	}
+	conn, err := dbIn.db.Conn(ctx)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, false, err
+	}
+	var locked sql.NullInt64
+	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", name).Scan(&locked)
+	if err != nil {
+		conn.Close()
+		fmt.Println(err, trace.GetTrace())
+		return nil, false, err
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		conn.Close()
+		return nil, false, nil
+	}
+	unlock := func() {
+		var released sql.NullInt64
+		err := conn.QueryRowContext(context.Background(), "SELECT RELEASE_LOCK(?)", name).Scan(&released)
+		if err != nil {
+			fmt.Println(err, trace.GetTrace())
+		}
+		conn.Close()
+	}
+	return unlock, true, nil
+}
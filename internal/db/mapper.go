@@ -0,0 +1,144 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Struct tag used for column to field mapping
+const tagName = "db"
+
+// Cache of column to field index maps by struct type
+var fieldMaps sync.Map
+
+// QueryAll query rows data from database and maps them to slice of T.
+// Columns are mapped to struct fields by `db` tag or by case-insensitive field name.
+// NULL values are scanned as zero values, unless field is a pointer or sql.Scanner.
+func QueryAll[T any](ctx context.Context, dbIn *Db, query string, params []any) ([]*T, error) {
+	rows, err := dbIn.QueryRowsContext(ctx, query, params)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	defer rows.Close()
+	items, err := scanRows[T](rows)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	return items, nil
+}
+
+// QueryOne query one row data from database and maps it to T.
+// Returns sql.ErrNoRows if query returns no rows
+func QueryOne[T any](ctx context.Context, dbIn *Db, query string, params []any) (*T, error) {
+	items, err := QueryAll[T](ctx, dbIn, query, params)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return items[0], nil
+}
+
+// Scan all rows to slice of T
+func scanRows[T any](rows *sql.Rows) ([]*T, error) {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return nil, errors.New("db: can not map rows to non-struct type " + typ.String())
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	fields := fieldMap(typ)
+	items := make([]*T, 0)
+	for rows.Next() {
+		item := new(T)
+		value := reflect.ValueOf(item).Elem()
+		dest := make([]any, len(columns))
+		setters := make([]func(), 0, len(columns))
+		for i, column := range columns {
+			index, ok := fields[strings.ToLower(column)]
+			if !ok {
+				dest[i] = new(any)
+				continue
+			}
+			field := value.FieldByIndex(index)
+			if field.Kind() == reflect.Pointer || field.Addr().Type().Implements(scannerType) {
+				dest[i] = field.Addr().Interface()
+				continue
+			}
+			// Scan to pointer to pointer, so NULL values become nil instead of error
+			holder := reflect.New(reflect.PointerTo(field.Type()))
+			dest[i] = holder.Interface()
+			setters = append(setters, func() {
+				if !holder.Elem().IsNil() {
+					field.Set(holder.Elem().Elem())
+				}
+			})
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for _, set := range setters {
+			set()
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+var scannerType = reflect.TypeFor[sql.Scanner]()
+
+// Returns map of lowercased column names to struct field indexes
+func fieldMap(typ reflect.Type) map[string][]int {
+	if cached, ok := fieldMaps.Load(typ); ok {
+		return cached.(map[string][]int)
+	}
+	fields := make(map[string][]int)
+	collectFields(typ, nil, fields)
+	fieldMaps.Store(typ, fields)
+	return fields
+}
+
+// Collect exported fields including fields of embedded structs
+func collectFields(typ reflect.Type, parent []int, fields map[string][]int) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		index := append(append([]int{}, parent...), i)
+		tag := field.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && tag == "" {
+			collectFields(field.Type, index, fields)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name := tag
+		if name == "" {
+			name = field.Name
+		}
+		name = strings.ToLower(name)
+		if _, ok := fields[name]; !ok {
+			fields[name] = index
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package direct
+
+import (
+	"context"
+	"slices"
+)
+
+// Max campaign ids of changes.check request
+const changesCheckLimit = 3000
+
+// Value of ChangesIn: campaign statistics changed
+const ChangesInStat = "STAT"
+
+// CampaignChanges define changed campaign of changes.checkCampaigns
+type CampaignChanges struct {
+	CampaignId int64    `json:"CampaignId"`
+	ChangesIn  []string `json:"ChangesIn"`
+}
+
+// CampaignStat define campaign statistics change of changes.check:
+// statistics changed for dates starting from border date
+type CampaignStat struct {
+	CampaignId int64  `json:"CampaignId"`
+	BorderDate string `json:"BorderDate"`
+}
+
+// Timestamp returns current server timestamp of changes service
+func (c *Client) Timestamp(ctx context.Context) (string, error) {
+	result := struct {
+		Timestamp string `json:"Timestamp"`
+	}{}
+	_, err := c.Call(ctx, "changes", "checkDictionaries", map[string]any{}, &result)
+	if err != nil {
+		return "", err
+	}
+	return result.Timestamp, nil
+}
+
+// CheckCampaigns returns campaigns changed since timestamp and new server timestamp
+func (c *Client) CheckCampaigns(ctx context.Context, timestamp string) ([]CampaignChanges, string, error) {
+	result := struct {
+		Campaigns []CampaignChanges `json:"Campaigns"`
+		Timestamp string            `json:"Timestamp"`
+	}{}
+	_, err := c.Call(ctx, "changes", "checkCampaigns", map[string]any{"Timestamp": timestamp}, &result)
+	if err != nil {
+		return nil, "", err
+	}
+	return result.Campaigns, result.Timestamp, nil
+}
+
+// CheckStat returns statistics changes of campaigns since timestamp
+func (c *Client) CheckStat(ctx context.Context, campaignIds []int64, timestamp string) ([]CampaignStat, error) {
+	stats := []CampaignStat{}
+	for chunk := range slices.Chunk(campaignIds, changesCheckLimit) {
+		result := struct {
+			CampaignsStat []CampaignStat `json:"CampaignsStat"`
+		}{}
+		params := map[string]any{
+			"CampaignIds": chunk,
+			"FieldNames":  []string{"CampaignsStat"},
+			"Timestamp":   timestamp,
+		}
+		_, err := c.Call(ctx, "changes", "check", params, &result)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, result.CampaignsStat...)
+	}
+	return stats, nil
+}
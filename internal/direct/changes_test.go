@@ -0,0 +1,54 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package direct
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCheckStatChunks(t *testing.T) {
+	requests := []int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := struct {
+			Method string `json:"method"`
+			Params struct {
+				CampaignIds []int64 `json:"CampaignIds"`
+			} `json:"params"`
+		}{}
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil || r.URL.Path != "/changes" || payload.Method != "check" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		ids := payload.Params.CampaignIds
+		requests = append(requests, len(ids))
+		stats := []CampaignStat{}
+		for _, id := range ids {
+			stats = append(stats, CampaignStat{CampaignId: id, BorderDate: "2024-05-01"})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": map[string]any{"CampaignsStat": stats}})
+	}))
+	defer server.Close()
+	campaignIds := []int64{}
+	for id := range int64(2*changesCheckLimit + 1) {
+		campaignIds = append(campaignIds, id+1)
+	}
+	client := NewClient(server.URL, "token", time.Second)
+	stats, err := client.CheckStat(context.Background(), campaignIds, "2024-05-01T00:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != len(campaignIds) {
+		t.Errorf("stats of %d campaigns, want %d", len(stats), len(campaignIds))
+	}
+	want := []int{changesCheckLimit, changesCheckLimit, 1}
+	if !slices.Equal(requests, want) {
+		t.Errorf("requests with %v campaign ids, want %v", requests, want)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package direct
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Default JSON API address
+const DefaultApiUrl = "https://api.direct.yandex.com/json/v5/"
+
+// Default page size of get methods
+const DefaultPageLimit = 10000
+
+// Units define API units of the response: spent by request, left and daily limit
+type Units struct {
+	Spent int
+	Left  int
+	Limit int
+}
+
+// Response define API response metadata
+type Response struct {
+	RequestId  string
+	StatusCode int
+	Units      Units
+}
+
+// Client define Yandex Direct JSON API client
+type Client struct {
+	apiUrl   string
+	token    string
+	login    string
+	language string
+	http     *http.Client
+}
+
+// Returns new API client, DefaultApiUrl is used if apiUrl is empty
+func NewClient(apiUrl string, token string, timeout time.Duration) *Client {
+	if apiUrl == "" {
+		apiUrl = DefaultApiUrl
+	}
+	return &Client{
+		apiUrl:   strings.TrimRight(apiUrl, "/") + "/",
+		token:    token,
+		language: "en",
+		http:     &http.Client{Timeout: timeout},
+	}
+}
+
+// WithLogin returns client copy for agency client login (Client-Login header)
+func (c *Client) WithLogin(login string) *Client {
+	clone := *c
+	clone.login = login
+	return &clone
+}
+
+// Call service method with params and decode result field of response to result.
+// API errors are returned as *Error
+func (c *Client) Call(ctx context.Context, service string, method string, params any, result any) (*Response, error) {
+	payload, err := json.Marshal(map[string]any{"method": method, "params": params})
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiUrl+service, bytes.NewReader(payload))
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept-Language", c.language)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if c.login != "" {
+		req.Header.Set("Client-Login", c.login)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	meta := &Response{RequestId: resp.Header.Get("RequestId"), StatusCode: resp.StatusCode}
+	fmt.Sscanf(resp.Header.Get("Units"), "%d/%d/%d", &meta.Units.Spent, &meta.Units.Left, &meta.Units.Limit)
+	if apiErr := ParseError(body); apiErr != nil {
+		return meta, apiErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return meta, &Error{RequestId: meta.RequestId, ErrorString: resp.Status}
+	}
+	data := struct {
+		Result json.RawMessage `json:"result"`
+	}{}
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return meta, fmt.Errorf("%s.%s: invalid response: %w", service, method, err)
+	}
+	if result != nil && len(data.Result) > 0 {
+		err = json.Unmarshal(data.Result, result)
+		if err != nil {
+			return meta, fmt.Errorf("%s.%s: invalid result: %w", service, method, err)
+		}
+	}
+	return meta, nil
+}
+
+// GetAll calls get method of service page by page while result is limited (LimitedBy is set)
+// and returns items of result field itemsKey, e.g. "Campaigns". Page limit 0 means DefaultPageLimit
+func GetAll[T any](ctx context.Context, c *Client, service string, params map[string]any, itemsKey string, pageLimit int64) ([]T, error) {
+	if pageLimit <= 0 {
+		pageLimit = DefaultPageLimit
+	}
+	pageParams := make(map[string]any, len(params)+1)
+	for key, value := range params {
+		pageParams[key] = value
+	}
+	items := []T{}
+	var offset int64
+	for {
+		pageParams["Page"] = map[string]int64{"Limit": pageLimit, "Offset": offset}
+		result := map[string]json.RawMessage{}
+		_, err := c.Call(ctx, service, "get", pageParams, &result)
+		if err != nil {
+			return nil, err
+		}
+		if raw, ok := result[itemsKey]; ok {
+			page := []T{}
+			err = json.Unmarshal(raw, &page)
+			if err != nil {
+				return nil, fmt.Errorf("%s.get: invalid %s: %w", service, itemsKey, err)
+			}
+			items = append(items, page...)
+		}
+		raw, ok := result["LimitedBy"]
+		if !ok {
+			return items, nil
+		}
+		var limitedBy int64
+		err = json.Unmarshal(raw, &limitedBy)
+		if err != nil || limitedBy <= offset {
+			return nil, fmt.Errorf("%s.get: invalid LimitedBy %s", service, raw)
+		}
+		offset = limitedBy
+	}
+}
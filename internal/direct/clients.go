@@ -0,0 +1,47 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package direct
+
+import (
+	"context"
+)
+
+// Values of Archived field
+const (
+	ArchivedYes = "YES"
+	ArchivedNo  = "NO"
+)
+
+// Client fields requested from clients and agencyclients services
+var clientFieldNames = []string{"Login", "ClientId", "ClientInfo", "Archived", "Currency", "VatRate"}
+
+// ClientInfo define advertiser of clients and agencyclients services
+type ClientInfo struct {
+	Login      string  `json:"Login"`
+	ClientId   int64   `json:"ClientId"`
+	ClientInfo string  `json:"ClientInfo"`
+	Archived   string  `json:"Archived"`
+	Currency   string  `json:"Currency"`
+	VatRate    float64 `json:"VatRate"`
+}
+
+// AgencyClients returns all clients of agency, including archived clients
+func (c *Client) AgencyClients(ctx context.Context) ([]ClientInfo, error) {
+	params := map[string]any{
+		"SelectionCriteria": map[string]any{},
+		"FieldNames":        clientFieldNames,
+	}
+	return GetAll[ClientInfo](ctx, c, "agencyclients", params, "Clients", 0)
+}
+
+// Clients returns advertiser of token, or agency client if client login is set
+func (c *Client) Clients(ctx context.Context) ([]ClientInfo, error) {
+	result := struct {
+		Clients []ClientInfo `json:"Clients"`
+	}{}
+	_, err := c.Call(ctx, "clients", "get", map[string]any{"FieldNames": clientFieldNames}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Clients, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package direct
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Dimension names
+const (
+	DimensionCampaigns = "campaigns"
+	DimensionAdGroups  = "adgroups"
+	DimensionAds       = "ads"
+	DimensionKeywords  = "keywords"
+)
+
+// All dimension names, campaigns are first: campaign ids are required by other dimensions
+var DimensionNames = []string{DimensionCampaigns, DimensionAdGroups, DimensionAds, DimensionKeywords}
+
+// Max campaign ids of adgroups, ads and keywords selection criteria
+const campaignIdsLimit = 10
+
+// Dimension define get request of dimension service
+type dimension struct {
+	service  string
+	itemsKey string
+	params   map[string]any
+}
+
+// Get requests of dimensions
+var dimensions = map[string]dimension{
+	DimensionCampaigns: {
+		service:  "campaigns",
+		itemsKey: "Campaigns",
+		params: map[string]any{
+			"FieldNames": []string{"Id", "Name", "Type", "Status", "State", "StatusPayment", "StartDate", "EndDate", "Currency"},
+		},
+	},
+	DimensionAdGroups: {
+		service:  "adgroups",
+		itemsKey: "AdGroups",
+		params: map[string]any{
+			"FieldNames": []string{"Id", "CampaignId", "Name", "Status", "Type", "RegionIds"},
+		},
+	},
+	DimensionAds: {
+		service:  "ads",
+		itemsKey: "Ads",
+		params: map[string]any{
+			"FieldNames":       []string{"Id", "CampaignId", "AdGroupId", "Status", "State", "Type", "Subtype"},
+			"TextAdFieldNames": []string{"Title", "Title2", "Text", "Href", "DisplayDomain"},
+		},
+	},
+	DimensionKeywords: {
+		service:  "keywords",
+		itemsKey: "Keywords",
+		params: map[string]any{
+			"FieldNames": []string{"Id", "CampaignId", "AdGroupId", "Keyword", "State", "Status"},
+		},
+	},
+}
+
+// Dimension returns raw items of dimension. Campaigns are requested for all campaigns,
+// other dimensions are requested for campaign ids. Page limit 0 means DefaultPageLimit
+func (c *Client) Dimension(ctx context.Context, name string, campaignIds []int64, pageLimit int64) ([]json.RawMessage, error) {
+	dim, ok := dimensions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown dimension %q", name)
+	}
+	if name == DimensionCampaigns {
+		params := maps.Clone(dim.params)
+		params["SelectionCriteria"] = map[string]any{}
+		return GetAll[json.RawMessage](ctx, c, dim.service, params, dim.itemsKey, pageLimit)
+	}
+	items := []json.RawMessage{}
+	for chunk := range slices.Chunk(campaignIds, campaignIdsLimit) {
+		params := maps.Clone(dim.params)
+		params["SelectionCriteria"] = map[string]any{"CampaignIds": chunk}
+		page, err := GetAll[json.RawMessage](ctx, c, dim.service, params, dim.itemsKey, pageLimit)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+	}
+	return items, nil
+}
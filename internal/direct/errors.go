@@ -0,0 +1,72 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for working with Yandex Direct API
+package direct
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// API error codes of authorization errors
+const (
+	ErrorCodeAuthUnavailable = 52 // Authorization server temporarily unavailable
+	ErrorCodeAuth            = 53 // Authorization error: invalid, expired or revoked token
+	ErrorCodeNoRights        = 54 // No access rights, e.g. agency service called with token of advertiser
+)
+
+// IsNotAgencyError returns true if agency service is not available for token: token of direct advertiser
+func IsNotAgencyError(err error) bool {
+	apiErr := &Error{}
+	return errors.As(err, &apiErr) && apiErr.ErrorCode == ErrorCodeNoRights
+}
+
+// Error define Yandex Direct API error
+type Error struct {
+	RequestId   string
+	ErrorCode   int
+	ErrorString string
+	ErrorDetail string
+}
+
+// Error returns error text
+func (e *Error) Error() string {
+	text := "direct api error " + strconv.Itoa(e.ErrorCode) + ": " + e.ErrorString
+	if e.ErrorDetail != "" {
+		text += ": " + e.ErrorDetail
+	}
+	return text
+}
+
+// ParseError returns API error from response body or nil if body has no error.
+// Reports service returns error code as string, other services as number
+func ParseError(body []byte) *Error {
+	data := struct {
+		Error *struct {
+			RequestId   string      `json:"request_id"`
+			ErrorCode   json.Number `json:"error_code"`
+			ErrorString string      `json:"error_string"`
+			ErrorDetail string      `json:"error_detail"`
+		} `json:"error"`
+	}{}
+	if json.Unmarshal(body, &data) != nil || data.Error == nil {
+		return nil
+	}
+	code, _ := data.Error.ErrorCode.Int64()
+	return &Error{
+		RequestId:   data.Error.RequestId,
+		ErrorCode:   int(code),
+		ErrorString: data.Error.ErrorString,
+		ErrorDetail: data.Error.ErrorDetail,
+	}
+}
+
+// IsAuthError returns true if response means that token is invalid or expired
+func IsAuthError(statusCode int, apiErr *Error) bool {
+	if statusCode == http.StatusUnauthorized {
+		return true
+	}
+	return apiErr != nil && apiErr.ErrorCode == ErrorCodeAuth
+}
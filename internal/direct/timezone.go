@@ -0,0 +1,28 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package direct
+
+import (
+	"context"
+)
+
+// Timezone returns account timezone: most frequent timezone of campaigns, empty if account has no campaigns
+func (c *Client) Timezone(ctx context.Context) (string, error) {
+	params := map[string]any{
+		"SelectionCriteria": map[string]any{},
+		"FieldNames":        []string{"Id", "TimeZone"},
+	}
+	campaigns, err := GetAll[struct{ TimeZone string }](ctx, c, "campaigns", params, "Campaigns", 0)
+	if err != nil {
+		return "", err
+	}
+	counts := map[string]int{}
+	timezone := ""
+	for _, campaign := range campaigns {
+		counts[campaign.TimeZone]++
+		if counts[campaign.TimeZone] > counts[timezone] {
+			timezone = campaign.TimeZone
+		}
+	}
+	return timezone, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for working with integration goals.
+// Goals of integration are added to report requests to get conversions by goal and attribution model.
+package goals
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/db"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Goal define integration goal
+type Goal struct {
+	IntegrationId int   `db:"integration_id"`
+	GoalId        int64 `db:"goal_id"`
+}
+
+// Store define integration goals store in database
+type Store struct {
+	db *db.Db
+}
+
+// Returns new integration goals store
+func NewStore(dbIn *db.Db) *Store {
+	return &Store{db: dbIn}
+}
+
+// Goals returns goal ids of integration
+func (s *Store) Goals(ctx context.Context, integrationId int) ([]int64, error) {
+	query := "SELECT integration_id, goal_id FROM ydloader_goals WHERE integration_id = ? ORDER BY goal_id"
+	items, err := db.QueryAll[Goal](ctx, s.db, query, []any{integrationId})
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.GoalId)
+	}
+	return ids, nil
+}
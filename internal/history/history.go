@@ -0,0 +1,212 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for working with run history.
+// Run history stores every loader run and every API request attempt of the run.
+package history
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/db"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Run statuses
+const (
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	StatusPartial = "partial"
+	StatusFailed  = "failed"
+)
+
+// Datetime format for history tables
+const timeFormat = "2006-01-02 15:04:05"
+
+// Run define one loader run
+type Run struct {
+	Id            int64  `db:"id"`
+	Report        string `db:"report"`
+	Environment   string `db:"environment"` // production or sandbox
+	StartedAt     string `db:"started_at"`
+	FinishedAt    string `db:"finished_at"`
+	Status        string `db:"status"`
+	JobsTotal     int    `db:"jobs_total"`
+	JobsSucceeded int    `db:"jobs_succeeded"`
+	JobsFailed    int    `db:"jobs_failed"`
+	QualityFailed int    `db:"quality_failed"` // Jobs with failed data quality rules
+	Error         string `db:"error"`
+}
+
+// Attempt define one API request attempt of the job
+type Attempt struct {
+	Id         int64  `db:"id"`
+	RunId      int64  `db:"run_id"`
+	Login      string `db:"login"`
+	ReportName string `db:"report_name"`
+	DateFrom   string `db:"date_from"`
+	DateTo     string `db:"date_to"`
+	Attempt    int    `db:"attempt"`
+	StatusCode int    `db:"status_code"`
+	RetryIn    int    `db:"retry_in"`
+	UnitsSpent int    `db:"units_spent"`
+	UnitsLeft  int    `db:"units_left"`
+	UnitsLimit int    `db:"units_limit"`
+	Bytes      int64  `db:"bytes"`
+	FilePath   string `db:"file_path"`
+	Error      string `db:"error"`
+	CreatedAt  string `db:"created_at"`
+}
+
+// SetUnits parse API Units header value "spent/left/limit"
+func (a *Attempt) SetUnits(units string) {
+	parts := strings.Split(units, "/")
+	if len(parts) != 3 {
+		return
+	}
+	a.UnitsSpent, _ = strconv.Atoi(parts[0])
+	a.UnitsLeft, _ = strconv.Atoi(parts[1])
+	a.UnitsLimit, _ = strconv.Atoi(parts[2])
+}
+
+// Filter define runs query filter
+type Filter struct {
+	Report string // Runs of report spec
+	Login  string // Runs with attempts for login
+	Status string // Runs with status
+	Limit  int    // Max runs count
+}
+
+// Store define run history store of environment in database
+type Store struct {
+	db          *db.Db
+	environment string
+}
+
+// Returns new run history store of environment: production or sandbox
+func NewStore(dbIn *db.Db, environment string) *Store {
+	return &Store{db: dbIn, environment: environment}
+}
+
+// StartRun create new run of report spec with running status.
+// Returns run id
+func (s *Store) StartRun(ctx context.Context, report string) (int64, error) {
+	sql := "INSERT INTO ydloader_runs (report, environment, started_at, status) VALUES (?, ?, ?, ?)"
+	res, err := s.db.ExecContext(ctx, sql, []any{report, s.environment, time.Now().UTC().Format(timeFormat), StatusRunning})
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// FinishRun update run status and jobs counters
+func (s *Store) FinishRun(ctx context.Context, run *Run) error {
+	sql := []string{
+		"UPDATE ydloader_runs SET",
+		"finished_at = ?, status = ?, jobs_total = ?, jobs_succeeded = ?, jobs_failed = ?, quality_failed = ?, error = ?",
+		"WHERE id = ?",
+	}
+	params := []any{
+		time.Now().UTC().Format(timeFormat),
+		run.Status,
+		run.JobsTotal,
+		run.JobsSucceeded,
+		run.JobsFailed,
+		run.QualityFailed,
+		run.Error,
+		run.Id,
+	}
+	_, err := s.db.ExecContext(ctx, strings.Join(sql, " "), params)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	return nil
+}
+
+// AddAttempt store job attempt
+func (s *Store) AddAttempt(ctx context.Context, a *Attempt) error {
+	sql := []string{
+		"INSERT INTO ydloader_attempts",
+		"(run_id, login, report_name, date_from, date_to, attempt, status_code, retry_in,",
+		"units_spent, units_left, units_limit, bytes, file_path, error, created_at)",
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+	}
+	params := []any{
+		a.RunId, a.Login, a.ReportName, a.DateFrom, a.DateTo, a.Attempt, a.StatusCode, a.RetryIn,
+		a.UnitsSpent, a.UnitsLeft, a.UnitsLimit, a.Bytes, a.FilePath, a.Error,
+		time.Now().UTC().Format(timeFormat),
+	}
+	res, err := s.db.ExecContext(ctx, strings.Join(sql, " "), params)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	a.Id, _ = res.LastInsertId()
+	return nil
+}
+
+// Runs returns recent runs by filter, newest first
+func (s *Store) Runs(ctx context.Context, filter Filter) ([]*Run, error) {
+	sql := []string{
+		"SELECT r.id, r.report, r.environment, DATE_FORMAT(r.started_at, '%Y-%m-%d %H:%i:%s') AS started_at,",
+		"IFNULL(DATE_FORMAT(r.finished_at, '%Y-%m-%d %H:%i:%s'), '') AS finished_at,",
+		"r.status, r.jobs_total, r.jobs_succeeded, r.jobs_failed, r.quality_failed,",
+		"IFNULL(r.error, '') AS error",
+		"FROM ydloader_runs r",
+		"WHERE r.environment = ?",
+	}
+	params := []any{s.environment}
+	if filter.Report != "" {
+		sql = append(sql, "AND r.report = ?")
+		params = append(params, filter.Report)
+	}
+	if filter.Status != "" {
+		sql = append(sql, "AND r.status = ?")
+		params = append(params, filter.Status)
+	}
+	if filter.Login != "" {
+		sql = append(sql, "AND EXISTS (SELECT 1 FROM ydloader_attempts a WHERE a.run_id = r.id AND a.login = ?)")
+		params = append(params, filter.Login)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	sql = append(sql, "ORDER BY r.id DESC LIMIT ?")
+	params = append(params, limit)
+	runs, err := db.QueryAll[Run](ctx, s.db, strings.Join(sql, " "), params)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	return runs, nil
+}
+
+// Attempts returns run attempts, optionally filtered by login
+func (s *Store) Attempts(ctx context.Context, runId int64, login string) ([]*Attempt, error) {
+	sql := []string{
+		"SELECT id, run_id, login, report_name,",
+		"DATE_FORMAT(date_from, '%Y-%m-%d') AS date_from, DATE_FORMAT(date_to, '%Y-%m-%d') AS date_to,",
+		"attempt, status_code, retry_in, units_spent, units_left, units_limit, bytes, file_path,",
+		"IFNULL(error, '') AS error, DATE_FORMAT(created_at, '%Y-%m-%d %H:%i:%s') AS created_at",
+		"FROM ydloader_attempts",
+		"WHERE run_id = ?",
+	}
+	params := []any{runId}
+	if login != "" {
+		sql = append(sql, "AND login = ?")
+		params = append(params, login)
+	}
+	sql = append(sql, "ORDER BY id")
+	attempts, err := db.QueryAll[Attempt](ctx, s.db, strings.Join(sql, " "), params)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	return attempts, nil
+}
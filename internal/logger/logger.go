@@ -1,117 +1,242 @@
 // Copyright 2024 Aleksei Grigorev
 // https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
-// Package define interfaces, structures and functions for working with logs
+// Package define interfaces, structures and functions for working with logs.
+// Log is a leveled structured logger built on log/slog with text and JSON formats.
 package logger
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"slices"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
-// Define log instance
-type Log struct {
-	logger          log.Logger // Log instance
-	PrefixDelimiter string     // Prefix delimiter
-	PrintToStdout   bool       // Print to stdout flag
+// Log formats
+const (
+	FormatText = "text"
+	FormatJson = "json"
+)
+
+// Log levels by name
+var levels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// ParseLevel returns log level by name: debug, info, warn, error
+func ParseLevel(name string) (slog.Level, error) {
+	level, ok := levels[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", name)
+	}
+	return level, nil
 }
 
-// Returns Log instance
-func (l *Log) Log() *log.Logger {
-	return &l.logger
+// LevelWriter define writer which gets level of every record, like syslog sending records with severity
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level slog.Level, p []byte) (int, error)
 }
 
-// Print to stdout
-func (l *Log) printToStdoutInternal(params ...any) {
-	fmt.Println(params...)
+// Writer passing fixed level to level writer
+type levelAdapter struct {
+	writer LevelWriter
+	level  slog.Level
 }
 
-// Cheap integer to fixed-width decimal ASCII. Give a negative width to avoid zero-padding.
-func itoa(buf *[]byte, i int, wid int) {
-	// Assemble decimal in reverse order.
-	var b [20]byte
-	bp := len(b) - 1
-	for i >= 10 || wid > 1 {
-		wid--
-		q := i / 10
-		b[bp] = byte('0' + i - q*10)
-		bp--
-		i = q
+// Write data to level writer with adapter level
+func (a levelAdapter) Write(p []byte) (int, error) {
+	return a.writer.WriteLevel(a.level, p)
+}
+
+// Handler define handler for level writer.
+// Records are formatted by handler of their level, every handler writes with its level
+type levelHandler struct {
+	handlers map[slog.Level]slog.Handler // Handlers by debug, info, warn and error levels
+}
+
+// Returns handler of record level, custom levels use handler of nearest lower level
+func (h levelHandler) handler(level slog.Level) slog.Handler {
+	switch {
+	case level >= slog.LevelError:
+		return h.handlers[slog.LevelError]
+	case level >= slog.LevelWarn:
+		return h.handlers[slog.LevelWarn]
+	case level >= slog.LevelInfo:
+		return h.handlers[slog.LevelInfo]
 	}
-	// i < 10
-	b[bp] = byte('0' + i)
-	*buf = append(*buf, b[bp:]...)
-}
-
-// Format header for print log to stdout
-func (l *Log) formatHeader() string {
-	flag := l.logger.Flags()
-	if flag&(log.Ldate|log.Ltime|log.Lmicroseconds) != 0 {
-		buf := []byte{}
-		t := time.Now()
-		if flag&log.LUTC != 0 {
-			t = t.UTC()
+	return h.handlers[slog.LevelDebug]
+}
+
+// Enabled reports whether handler of level handles records of level
+func (h levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler(level).Enabled(ctx, level)
+}
+
+// Handle record by handler of record level
+func (h levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.handler(record.Level).Handle(ctx, record)
+}
+
+// WithAttrs returns handler which adds attributes to records of every level
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(map[slog.Level]slog.Handler, len(h.handlers))
+	for level, handler := range h.handlers {
+		handlers[level] = handler.WithAttrs(attrs)
+	}
+	return levelHandler{handlers: handlers}
+}
+
+// WithGroup returns handler which adds group to records of every level
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	handlers := make(map[slog.Level]slog.Handler, len(h.handlers))
+	for level, handler := range h.handlers {
+		handlers[level] = handler.WithGroup(name)
+	}
+	return levelHandler{handlers: handlers}
+}
+
+// Output define log output and format shared by logger and its derived loggers
+type output struct {
+	level   slog.LevelVar
+	handler atomic.Pointer[slog.Handler]
+	writer  atomic.Pointer[io.Writer]
+	format  atomic.Value
+}
+
+// Rebuild handler for current writer and format
+func (o *output) build() {
+	opts := &slog.HandlerOptions{Level: &o.level}
+	format := o.format.Load()
+	newHandler := func(writer io.Writer) slog.Handler {
+		if format == FormatJson {
+			return slog.NewJSONHandler(writer, opts)
 		}
-		if flag&log.Ldate != 0 {
-			year, month, day := t.Date()
-			itoa(&buf, year, 4)
-			buf = append(buf, '/')
-			itoa(&buf, int(month), 2)
-			buf = append(buf, '/')
-			itoa(&buf, day, 2)
-			buf = append(buf, ' ')
+		return slog.NewTextHandler(writer, opts)
+	}
+	writer := *o.writer.Load()
+	var handler slog.Handler
+	if levelWriter, ok := writer.(LevelWriter); ok {
+		handlers := map[slog.Level]slog.Handler{}
+		for _, level := range levels {
+			handlers[level] = newHandler(levelAdapter{writer: levelWriter, level: level})
 		}
-		if flag&(log.Ltime|log.Lmicroseconds) != 0 {
-			hour, min, sec := t.Clock()
-			itoa(&buf, hour, 2)
-			buf = append(buf, ':')
-			itoa(&buf, min, 2)
-			buf = append(buf, ':')
-			itoa(&buf, sec, 2)
-			if flag&log.Lmicroseconds != 0 {
-				buf = append(buf, '.')
-				itoa(&buf, t.Nanosecond()/1e3, 6)
-			}
-			buf = append(buf, ' ')
+		handler = levelHandler{handlers: handlers}
+	} else {
+		handler = newHandler(writer)
+	}
+	o.handler.Store(&handler)
+}
+
+// Define log instance
+type Log struct {
+	output *output
+	attrs  []slog.Attr // Attributes added to every record
+}
+
+// Returns new text logger writing info and higher levels to stdout
+func New() *Log {
+	o := &output{}
+	var writer io.Writer = os.Stdout
+	o.writer.Store(&writer)
+	o.format.Store(FormatText)
+	o.build()
+	return &Log{output: o}
+}
+
+// SetOutput set log writer of logger and all derived loggers
+func (l *Log) SetOutput(writer io.Writer) {
+	l.output.writer.Store(&writer)
+	l.output.build()
+}
+
+// Configure set log format: text or json, and minimum level: debug, info, warn or error
+func (l *Log) Configure(format string, level string) error {
+	if format != FormatText && format != FormatJson {
+		return fmt.Errorf("unknown log format %q, expected text or json", format)
+	}
+	minLevel, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.output.level.Set(minLevel)
+	if l.output.format.Swap(format) != format {
+		l.output.build()
+	}
+	return nil
+}
+
+// With returns logger which adds attributes to every record.
+// Arguments are key-value pairs or slog.Attr values
+func (l *Log) With(args ...any) *Log {
+	record := slog.Record{}
+	record.Add(args...)
+	attrs := make([]slog.Attr, 0, len(l.attrs)+record.NumAttrs())
+	attrs = append(attrs, l.attrs...)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	return &Log{output: l.output, attrs: attrs}
+}
+
+// Slog returns slog logger with logger attributes
+func (l *Log) Slog() *slog.Logger {
+	logger := slog.New(*l.output.handler.Load())
+	for _, attr := range l.attrs {
+		logger = logger.With(attr)
+	}
+	return logger
+}
+
+// Write record. Parameters are joined with spaces to message, slog.Attr parameters are added as attributes
+func (l *Log) write(level slog.Level, params []any) *Log {
+	handler := *l.output.handler.Load()
+	ctx := context.Background()
+	if !handler.Enabled(ctx, level) {
+		return l
+	}
+	parts := make([]string, 0, len(params))
+	attrs := []slog.Attr{}
+	for _, param := range params {
+		if attr, ok := param.(slog.Attr); ok {
+			attrs = append(attrs, attr)
+			continue
 		}
-		return string(buf)
+		parts = append(parts, strings.TrimSpace(fmt.Sprint(param)))
 	}
-	return ""
+	record := slog.NewRecord(time.Now(), level, strings.Join(parts, " "), 0)
+	record.AddAttrs(l.attrs...)
+	record.AddAttrs(attrs...)
+	err := handler.Handle(ctx, record)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "log write failed:", err)
+	}
+	return l
 }
 
 // Print error message
-func (log *Log) Error(params ...any) *Log {
-	prefix := "ERROR:" + log.PrefixDelimiter
-	log.Log().SetPrefix(prefix)
-	log.Log().Println(params...)
-	if log.PrintToStdout {
-		params = slices.Insert[[]any, any](params, 0, prefix, log.formatHeader())
-		log.printToStdoutInternal(params...)
-	}
-	return log
+func (l *Log) Error(params ...any) *Log {
+	return l.write(slog.LevelError, params)
+}
+
+// Print warning message
+func (l *Log) Warn(params ...any) *Log {
+	return l.write(slog.LevelWarn, params)
 }
 
 // Print info message
-func (log *Log) Info(params ...any) *Log {
-	prefix := "INFO:" + log.PrefixDelimiter
-	log.Log().SetPrefix(prefix)
-	log.Log().Println(params...)
-	if log.PrintToStdout {
-		params = slices.Insert[[]any, any](params, 0, prefix, log.formatHeader())
-		log.printToStdoutInternal(params...)
-	}
-	return log
+func (l *Log) Info(params ...any) *Log {
+	return l.write(slog.LevelInfo, params)
 }
 
 // Print debug message
-func (log *Log) Debug(params ...any) *Log {
-	prefix := "DEBUG:" + log.PrefixDelimiter
-	log.Log().SetPrefix(prefix)
-	log.Log().Println(params...)
-	if log.PrintToStdout {
-		params = slices.Insert[[]any, any](params, 0, prefix, log.formatHeader())
-		log.printToStdoutInternal(params...)
-	}
-	return log
+func (l *Log) Debug(params ...any) *Log {
+	return l.write(slog.LevelDebug, params)
 }
@@ -0,0 +1,82 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Level writer recording levels of written records
+type recordingWriter struct {
+	levels []slog.Level
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(slog.LevelInfo, p)
+}
+
+func (w *recordingWriter) WriteLevel(level slog.Level, p []byte) (int, error) {
+	w.levels = append(w.levels, level)
+	return len(p), nil
+}
+
+func TestLevelWriter(t *testing.T) {
+	writer := &recordingWriter{}
+	log := New()
+	log.SetOutput(Tee{writer})
+	err := log.Configure(FormatText, "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Debug("debug")
+	log.Info("info")
+	log.With("job", 1).Warn("warn")
+	log.Error("error")
+	log.Slog().Log(context.Background(), slog.LevelError+4, "custom")
+	want := []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError, slog.LevelError}
+	if !slices.Equal(writer.levels, want) {
+		t.Errorf("levels = %v, want %v", writer.levels, want)
+	}
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	file, err := NewRotatingFile(path, RotateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	// State after rotation which renamed file but failed to open new file
+	file.file.Close()
+	file.file = nil
+	file.retryAt = time.Now().Add(time.Hour)
+	_, err = file.Write([]byte("lost\n"))
+	if err == nil {
+		t.Fatal("write before retry time succeeded")
+	}
+	file.retryAt = time.Now()
+	_, err = file.Write([]byte("written\n"))
+	if err != nil {
+		t.Fatalf("write after retry time failed: %s", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(data)) != "written" {
+		t.Errorf("file = %q, want written record", data)
+	}
+	file.Close()
+	_, err = file.Write([]byte("closed\n"))
+	if !errors.Is(err, os.ErrClosed) {
+		t.Errorf("write after close = %v, want %v", err, os.ErrClosed)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package logger
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+)
+
+// Log outputs
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+	OutputSyslog = "syslog"
+)
+
+// Error of syslog output on platforms without syslog
+var ErrSyslogUnsupported = errors.New("syslog is not supported on this platform")
+
+// Tee define writer which writes to every writer.
+// Unlike io.MultiWriter, failed writer does not stop writing to other writers
+type Tee []io.Writer
+
+// Write data to every writer, returns first error
+func (t Tee) Write(p []byte) (int, error) {
+	return t.WriteLevel(slog.LevelInfo, p)
+}
+
+// Write data with level to every writer, level writers get record level. Returns first error
+func (t Tee) WriteLevel(level slog.Level, p []byte) (int, error) {
+	var firstErr error
+	for _, w := range t {
+		var err error
+		if levelWriter, ok := w.(LevelWriter); ok {
+			_, err = levelWriter.WriteLevel(level, p)
+		} else {
+			_, err = w.Write(p)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	return len(p), nil
+}
@@ -0,0 +1,264 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Time format of rotated file names
+const backupTimeFormat = "20060102T150405.000"
+
+// Delay of next rotation or reopen after failed rotation
+const rotateRetryDelay = time.Minute
+
+// Error of write while log file is not open after failed rotation
+var errNotReopened = errors.New("log file is not open, reopen is retried later")
+
+// RotateOptions define log file rotation and retention
+type RotateOptions struct {
+	MaxSize    int64 // Rotate file when size exceeds bytes, 0 - no size rotation
+	Daily      bool  // Rotate file when day changes
+	MaxAge     int   // Remove rotated files older than days, 0 - keep
+	MaxBackups int   // Keep max count of rotated files, 0 - keep all
+	Compress   bool  // Compress rotated files with gzip
+}
+
+// RotatingFile define log file writer with rotation.
+// Rotated files are named <name>-<time><ext>[.gz] in the log file directory
+type RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	opts     RotateOptions
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	retryAt  time.Time // Rotation and reopen are not tried before time after failed rotation
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// Returns new rotating log file, file and its directory are created if not exist
+func NewRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, opts: opts}
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	err = r.open()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Open log file for append
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	r.openedAt = time.Now()
+	// Existing file is rotated by day of its last write
+	if info.Size() > 0 {
+		r.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+// Write data to log file, rotates file before write if needed
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	// File is not open after failed rotation
+	if r.file == nil {
+		if time.Now().Before(r.retryAt) {
+			return 0, errNotReopened
+		}
+		err := r.reopen()
+		if err != nil {
+			return 0, err
+		}
+	}
+	if r.needRotate(int64(len(p)), time.Now()) {
+		err := r.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Returns true if file must be rotated before write
+func (r *RotatingFile) needRotate(size int64, now time.Time) bool {
+	if r.size == 0 || now.Before(r.retryAt) {
+		return false
+	}
+	if r.opts.MaxSize > 0 && r.size+size > r.opts.MaxSize {
+		return true
+	}
+	if r.opts.Daily {
+		y1, m1, d1 := r.openedAt.Date()
+		y2, m2, d2 := now.Date()
+		return y1 != y2 || m1 != m2 || d1 != d2
+	}
+	return false
+}
+
+// Open log file, open is retried later if it fails
+func (r *RotatingFile) reopen() error {
+	err := r.open()
+	if err != nil {
+		r.retryAt = time.Now().Add(rotateRetryDelay)
+	}
+	return err
+}
+
+// Rotate rename current file to backup, open new file, compress and clean backups in background.
+// If file can't be renamed, current file is reopened and rotation is retried later.
+// If file can't be opened, it is reopened by write after retry delay
+func (r *RotatingFile) rotate() error {
+	err := r.file.Close()
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+	}
+	r.file = nil
+	ext := filepath.Ext(r.path)
+	backup := strings.TrimSuffix(r.path, ext) + "-" + time.Now().Format(backupTimeFormat) + ext
+	err = os.Rename(r.path, backup)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		r.retryAt = time.Now().Add(rotateRetryDelay)
+		return r.reopen()
+	}
+	err = r.reopen()
+	if err != nil {
+		return err
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		if r.opts.Compress {
+			err := compressFile(backup)
+			if err != nil {
+				fmt.Println(err, trace.GetTrace())
+			}
+		}
+		r.cleanup()
+	}()
+	return nil
+}
+
+// Compress file with gzip and remove source file
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
+
+// Returns rotated files, newest first
+func (r *RotatingFile) backups() ([]string, error) {
+	ext := filepath.Ext(r.path)
+	pattern := strings.TrimSuffix(r.path, ext) + "-*" + ext
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := filepath.Glob(pattern + ".gz")
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, compressed...)
+	// Names contain rotation time, so names order is rotation order.
+	// Pattern of path without extension matches compressed files too
+	slices.Sort(files)
+	files = slices.Compact(files)
+	slices.Reverse(files)
+	return files, nil
+}
+
+// Remove rotated files exceeding max backups and max age
+func (r *RotatingFile) cleanup() {
+	if r.opts.MaxBackups <= 0 && r.opts.MaxAge <= 0 {
+		return
+	}
+	files, err := r.backups()
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return
+	}
+	cutoff := time.Now().AddDate(0, 0, -r.opts.MaxAge)
+	for i, file := range files {
+		remove := r.opts.MaxBackups > 0 && i >= r.opts.MaxBackups
+		if !remove && r.opts.MaxAge > 0 {
+			info, err := os.Stat(file)
+			remove = err == nil && info.ModTime().Before(cutoff)
+		}
+		if remove {
+			err = os.Remove(file)
+			if err != nil {
+				fmt.Println(err, trace.GetTrace())
+			}
+		}
+	}
+}
+
+// Close log file, waits for background compression
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.wg.Wait()
+	r.closed = true
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
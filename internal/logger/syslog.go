@@ -0,0 +1,45 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"log/syslog"
+)
+
+// Syslog writer, records are sent with severity of their level
+type syslogWriter struct {
+	*syslog.Writer
+}
+
+// Returns syslog writer. Empty network and address connect to local syslog daemon
+func NewSyslog(network string, address string, tag string) (io.WriteCloser, error) {
+	writer, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, err
+	}
+	return syslogWriter{writer}, nil
+}
+
+// Write record with syslog severity of level: error, warning, info or debug
+func (s syslogWriter) WriteLevel(level slog.Level, p []byte) (int, error) {
+	var err error
+	switch {
+	case level >= slog.LevelError:
+		err = s.Err(string(p))
+	case level >= slog.LevelWarn:
+		err = s.Warning(string(p))
+	case level >= slog.LevelInfo:
+		err = s.Info(string(p))
+	default:
+		err = s.Debug(string(p))
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
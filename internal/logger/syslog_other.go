@@ -0,0 +1,15 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+
+//go:build windows || plan9
+
+package logger
+
+import (
+	"io"
+)
+
+// Returns ErrSyslogUnsupported, syslog is not available on this platform
+func NewSyslog(network string, address string, tag string) (io.WriteCloser, error) {
+	return nil, ErrSyslogUnsupported
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for working with login source.
+// Login source stores integrations (tokens) and client logins of every integration.
+package logins
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/db"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+	"github.com/AlekseiGrigorev/ydloader/models/integrations"
+	"github.com/AlekseiGrigorev/ydloader/models/ydirectlogins"
+)
+
+// Source define interface for login source
+type Source interface {
+	// Integrations returns active integrations with tokens
+	Integrations(ctx context.Context) ([]*integrations.Integration, error)
+	// Logins returns client logins of integration
+	Logins(ctx context.Context, integrationId int) ([]*ydirectlogins.IntegrationLogin, error)
+	// Add link login to integration, login is created if not exists
+	Add(ctx context.Context, integrationId int, login string) error
+	// Unlink remove login from integration
+	Unlink(ctx context.Context, integrationId int, login string) error
+}
+
+// Store define login source in smartis_stat database
+type Store struct {
+	db *db.Db
+}
+
+// Returns new login source store
+func NewStore(dbIn *db.Db) *Store {
+	return &Store{db: dbIn}
+}
+
+// Integrations returns active integrations with tokens
+func (s *Store) Integrations(ctx context.Context) ([]*integrations.Integration, error) {
+	model := integrations.Integration{}
+	items, err := db.QueryAll[integrations.Integration](ctx, s.db, model.GetDefaultSql(), nil)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	return items, nil
+}
+
+// Logins returns client logins of integration
+func (s *Store) Logins(ctx context.Context, integrationId int) ([]*ydirectlogins.IntegrationLogin, error) {
+	model := ydirectlogins.IntegrationLogin{}
+	items, err := db.QueryAll[ydirectlogins.IntegrationLogin](ctx, s.db, model.GetDefaultSql(), []any{integrationId})
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	return items, nil
+}
+
+// Add link login to integration, login is created if not exists
+func (s *Store) Add(ctx context.Context, integrationId int, login string) error {
+	query := "SELECT ydl.id, ydl.Login FROM smartis_stat.YDirect_Logins ydl WHERE ydl.Login = ?"
+	existing, err := db.QueryOne[ydirectlogins.IntegrationLogin](ctx, s.db, query, []any{login})
+	var loginId int64
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		res, err := s.db.ExecContext(ctx, "INSERT INTO smartis_stat.YDirect_Logins (Login) VALUES (?)", []any{login})
+		if err != nil {
+			fmt.Println(err, trace.GetTrace())
+			return err
+		}
+		loginId, err = res.LastInsertId()
+		if err != nil {
+			fmt.Println(err, trace.GetTrace())
+			return err
+		}
+	case err != nil:
+		fmt.Println(err, trace.GetTrace())
+		return err
+	default:
+		loginId = int64(existing.Id)
+	}
+	sql := []string{
+		"INSERT INTO smartis_stat.YDirect_integrations_logins (login_id, integration_id)",
+		"SELECT ?, ? FROM DUAL",
+		"WHERE NOT EXISTS (SELECT 1 FROM smartis_stat.YDirect_integrations_logins WHERE login_id = ? AND integration_id = ?)",
+	}
+	_, err = s.db.ExecContext(ctx, strings.Join(sql, " "), []any{loginId, integrationId, loginId, integrationId})
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	return nil
+}
+
+// Unlink remove login from integration, login itself is kept
+func (s *Store) Unlink(ctx context.Context, integrationId int, login string) error {
+	sql := []string{
+		"DELETE ydil FROM smartis_stat.YDirect_integrations_logins ydil",
+		"JOIN smartis_stat.YDirect_Logins ydl ON ydl.id = ydil.login_id",
+		"WHERE ydil.integration_id = ? AND ydl.Login = ?",
+	}
+	_, err := s.db.ExecContext(ctx, strings.Join(sql, " "), []any{integrationId, login})
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for application metrics.
+// Metrics are exposed in Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Content type of Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Default histogram buckets, seconds
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metric define metric family which can be written in text format
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// Registry define set of metrics
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	names   map[string]bool
+	hooks   []func()
+}
+
+// Returns new metrics registry
+func NewRegistry() *Registry {
+	return &Registry{names: map[string]bool{}}
+}
+
+// Register metric, panics if metric name is already registered
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.names[name] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// OnCollect add function called before metrics are written, e.g. to update gauges
+func (r *Registry) OnCollect(hook func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, hook)
+}
+
+// Counter returns new registered counter family
+func (r *Registry) Counter(name string, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec: newVec[Counter](name, help, "counter", labels)}
+	r.register(name, c)
+	return c
+}
+
+// Gauge returns new registered gauge family
+func (r *Registry) Gauge(name string, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{vec: newVec[Gauge](name, help, "gauge", labels)}
+	r.register(name, g)
+	return g
+}
+
+// Histogram returns new registered histogram family, DefBuckets are used if buckets are empty
+func (r *Registry) Histogram(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	h := &HistogramVec{vec: newVec[Histogram](name, help, "histogram", labels), buckets: buckets}
+	r.register(name, h)
+	return h
+}
+
+// Write metrics in Prometheus text format
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	hooks := slices.Clone(r.hooks)
+	metrics := slices.Clone(r.metrics)
+	r.mu.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+	buf := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(buf)
+	}
+	err := buf.Flush()
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	return nil
+}
+
+// Handler returns HTTP handler serving metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.Write(w)
+	})
+}
+
+// Labeled children of metric family
+type vec[T any] struct {
+	name     string
+	help     string
+	kind     string
+	labels   []string
+	mu       sync.Mutex
+	children map[string]*child[T]
+}
+
+// Child define metric with label values
+type child[T any] struct {
+	values []string
+	metric *T
+}
+
+// Returns new metric family
+func newVec[T any](name string, help string, kind string, labels []string) *vec[T] {
+	return &vec[T]{name: name, help: help, kind: kind, labels: labels, children: map[string]*child[T]{}}
+}
+
+// Returns child metric for label values, creates it with init if not exists
+func (v *vec[T]) with(values []string, init func() *T) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, ok := v.children[key]
+	if !ok {
+		c = &child[T]{values: slices.Clone(values), metric: init()}
+		v.children[key] = c
+	}
+	return c.metric
+}
+
+// Remove all children
+func (v *vec[T]) reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.children = map[string]*child[T]{}
+}
+
+// Returns children sorted by label values
+func (v *vec[T]) sorted() []*child[T] {
+	v.mu.Lock()
+	keys := make([]string, 0, len(v.children))
+	for key := range v.children {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	items := make([]*child[T], 0, len(keys))
+	for _, key := range keys {
+		items = append(items, v.children[key])
+	}
+	v.mu.Unlock()
+	return items
+}
+
+// Write HELP and TYPE lines
+func (v *vec[T]) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", v.name, escapeHelp(v.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.name, v.kind)
+}
+
+// Returns labels text: {a="1",b="2"}, extra label pair is appended if set
+func formatLabels(names []string, values []string, extraName string, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	parts := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		parts = append(parts, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extraName != "" {
+		parts = append(parts, extraName+`="`+escapeLabel(extraValue)+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// Returns float in Prometheus text format
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// Escape label value
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// Escape help text
+func escapeHelp(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(value)
+}
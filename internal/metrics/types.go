@@ -0,0 +1,161 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Float value with atomic updates
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+// Returns value
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+// Set value
+func (f *atomicFloat) store(value float64) {
+	f.bits.Store(math.Float64bits(value))
+}
+
+// Add delta to value
+func (f *atomicFloat) add(delta float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+// Counter define monotonically increasing value
+type Counter struct {
+	value atomicFloat
+}
+
+// Inc increment counter by 1
+func (c *Counter) Inc() {
+	c.value.add(1)
+}
+
+// Add increase counter by delta, negative delta is ignored
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		return
+	}
+	c.value.add(delta)
+}
+
+// CounterVec define counter family with labels
+type CounterVec struct {
+	*vec[Counter]
+}
+
+// With returns counter for label values
+func (c *CounterVec) With(values ...string) *Counter {
+	return c.with(values, func() *Counter { return &Counter{} })
+}
+
+// Write counters
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.writeHeader(w)
+	for _, item := range c.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, item.values, "", ""), formatFloat(item.metric.value.load()))
+	}
+}
+
+// Gauge define value which can go up and down
+type Gauge struct {
+	value atomicFloat
+}
+
+// Set gauge value
+func (g *Gauge) Set(value float64) {
+	g.value.store(value)
+}
+
+// Add delta to gauge value
+func (g *Gauge) Add(delta float64) {
+	g.value.add(delta)
+}
+
+// GaugeVec define gauge family with labels
+type GaugeVec struct {
+	*vec[Gauge]
+}
+
+// With returns gauge for label values
+func (g *GaugeVec) With(values ...string) *Gauge {
+	return g.with(values, func() *Gauge { return &Gauge{} })
+}
+
+// Reset remove all gauges of family
+func (g *GaugeVec) Reset() {
+	g.reset()
+}
+
+// Write gauges
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	for _, item := range g.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labels, item.values, "", ""), formatFloat(item.metric.value.load()))
+	}
+}
+
+// Histogram define distribution of observed values by buckets
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// Observe add value to histogram
+func (h *Histogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// HistogramVec define histogram family with labels
+type HistogramVec struct {
+	*vec[Histogram]
+	buckets []float64
+}
+
+// With returns histogram for label values
+func (h *HistogramVec) With(values ...string) *Histogram {
+	return h.with(values, func() *Histogram {
+		return &Histogram{buckets: h.buckets, counts: make([]uint64, len(h.buckets))}
+	})
+}
+
+// Write histograms: cumulative buckets, sum and count
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.writeHeader(w)
+	for _, item := range h.sorted() {
+		hist := item.metric
+		hist.mu.Lock()
+		for i, bound := range hist.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, item.values, "le", formatFloat(bound)), hist.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, item.values, "le", "+Inf"), hist.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, item.values, "", ""), formatFloat(hist.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, item.values, "", ""), hist.count)
+		hist.mu.Unlock()
+	}
+}
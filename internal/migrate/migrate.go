@@ -0,0 +1,271 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for database schema migrations.
+// Migrations are versioned SQL files embedded in the binary, applied migrations
+// are stored in bookkeeping table.
+package migrate
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/db"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Bookkeeping table name
+const Table = "ydloader_schema_migrations"
+
+//go:embed migrations
+var files embed.FS
+
+// Migration file name pattern: <version>_<name>.<up|down>.sql
+var fileRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration define one versioned migration
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status define migration with applied state
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt string
+}
+
+// Applied migration row from bookkeeping table
+type appliedRow struct {
+	Version   int64  `db:"version"`
+	AppliedAt string `db:"applied_at"`
+}
+
+// Migrator apply and rollback migrations
+type Migrator struct {
+	db         *db.Db
+	migrations []Migration
+}
+
+// Returns new migrator for database with embedded migrations
+func New(dbIn *db.Db) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	return &Migrator{db: dbIn, migrations: migrations}, nil
+}
+
+// Load migrations from file system sorted by version
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		matches := fileRe.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(fsys, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		}
+		if m.Name != matches[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, m.Name, matches[2])
+		}
+		if matches[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Split migration file to statements by ";" at the end of line
+func statements(content string) []string {
+	result := []string{}
+	current := []string{}
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current = append(current, line)
+		if strings.HasSuffix(trimmed, ";") {
+			result = append(result, strings.Join(current, "\n"))
+			current = []string{}
+		}
+	}
+	if len(current) > 0 {
+		result = append(result, strings.Join(current, "\n"))
+	}
+	return result
+}
+
+// Create bookkeeping table if needed
+func (m *Migrator) init(ctx context.Context) error {
+	sql := []string{
+		"CREATE TABLE IF NOT EXISTS " + Table + " (",
+		"version BIGINT NOT NULL PRIMARY KEY,",
+		"name VARCHAR(255) NOT NULL,",
+		"applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP",
+		")",
+	}
+	_, err := m.db.ExecContext(ctx, strings.Join(sql, " "), nil)
+	return err
+}
+
+// Returns applied migrations versions with applied time
+func (m *Migrator) applied(ctx context.Context) (map[int64]string, error) {
+	err := m.init(ctx)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	sql := "SELECT version, DATE_FORMAT(applied_at, '%Y-%m-%d %H:%i:%s') AS applied_at FROM " + Table
+	rows, err := db.QueryAll[appliedRow](ctx, m.db, sql, nil)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	applied := map[int64]string{}
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+// Status returns all known migrations with applied state
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	result := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		result = append(result, Status{Migration: migration, Applied: ok, AppliedAt: appliedAt})
+	}
+	return result, nil
+}
+
+// Up apply pending migrations in ascending order.
+// Applies all pending migrations if steps <= 0.
+// Returns applied migrations
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	done := []Migration{}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if steps > 0 && len(done) >= steps {
+			break
+		}
+		err = m.exec(ctx, migration.Up)
+		if err != nil {
+			err = fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+			fmt.Println(err, trace.GetTrace())
+			return done, err
+		}
+		_, err = m.db.ExecContext(ctx, "INSERT INTO "+Table+" (version, name) VALUES (?, ?)", []any{migration.Version, migration.Name})
+		if err != nil {
+			fmt.Println(err, trace.GetTrace())
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down rollback applied migrations in descending order.
+// Rollbacks one migration if steps <= 0.
+// Returns rolled back migrations
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	done := []Migration{}
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			err = fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			fmt.Println(err, trace.GetTrace())
+			return done, err
+		}
+		err = m.exec(ctx, migration.Down)
+		if err != nil {
+			err = fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+			fmt.Println(err, trace.GetTrace())
+			return done, err
+		}
+		_, err = m.db.ExecContext(ctx, "DELETE FROM "+Table+" WHERE version = ?", []any{migration.Version})
+		if err != nil {
+			fmt.Println(err, trace.GetTrace())
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Execute migration file statements
+func (m *Migrator) exec(ctx context.Context, content string) error {
+	stmts := statements(content)
+	if len(stmts) == 0 {
+		return errors.New("migration file is empty")
+	}
+	for _, stmt := range stmts {
+		_, err := m.db.ExecContext(ctx, stmt, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package migrate
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %d_%s: version must be %d", m.Version, m.Name, i+1)
+		}
+		if len(statements(m.Up)) == 0 || len(statements(m.Down)) == 0 {
+			t.Errorf("migration %d_%s: up and down must have statements", m.Version, m.Name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_second.up.sql":   {Data: []byte("CREATE TABLE b (id INT);")},
+		"migrations/0001_first.up.sql":    {Data: []byte("CREATE TABLE a (id INT);")},
+		"migrations/0001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+		"migrations/README.md":            {Data: []byte("# Migrations")},
+		"migrations/0003_bad-name.up.sql": {Data: []byte("SELECT 1;")},
+	}
+	migrations, err := load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("got %d migrations, want 2", len(migrations))
+	}
+	first, second := migrations[0], migrations[1]
+	if first.Version != 1 || first.Name != "first" || first.Up == "" || first.Down == "" {
+		t.Errorf("first migration = %+v", first)
+	}
+	if second.Version != 2 || second.Name != "second" || second.Down != "" {
+		t.Errorf("second migration = %+v", second)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"different names", fstest.MapFS{
+			"migrations/0001_first.up.sql":   {Data: []byte("SELECT 1;")},
+			"migrations/0001_other.down.sql": {Data: []byte("SELECT 1;")},
+		}},
+		{"no up file", fstest.MapFS{
+			"migrations/0001_first.down.sql": {Data: []byte("SELECT 1;")},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(tt.fsys)
+			if err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"empty", "\n  \n", []string{}},
+		{"one line statements", "DROP TABLE a;\nDROP TABLE b;\n", []string{"DROP TABLE a;", "DROP TABLE b;"}},
+		{
+			"multi line statement and comments",
+			"-- create table\nCREATE TABLE a (\n    id INT\n);\n\nALTER TABLE a ADD COLUMN b INT;",
+			[]string{"CREATE TABLE a (\n    id INT\n);", "ALTER TABLE a ADD COLUMN b INT;"},
+		},
+		{"semicolon inside line", "INSERT INTO a VALUES ('x;y');\n", []string{"INSERT INTO a VALUES ('x;y');"}},
+		{"no trailing semicolon", "SELECT 1", []string{"SELECT 1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := statements(tt.content)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("statements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS ydloader_attempts;
+DROP TABLE IF EXISTS ydloader_runs;
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS ydloader_runs (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME NULL,
+    status VARCHAR(32) NOT NULL,
+    jobs_total INT NOT NULL DEFAULT 0,
+    jobs_succeeded INT NOT NULL DEFAULT 0,
+    jobs_failed INT NOT NULL DEFAULT 0,
+    error TEXT NULL,
+    KEY idx_ydloader_runs_started_at (started_at),
+    KEY idx_ydloader_runs_status (status)
+);
+
+CREATE TABLE IF NOT EXISTS ydloader_attempts (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    run_id BIGINT NOT NULL,
+    login VARCHAR(255) NOT NULL,
+    report_name VARCHAR(255) NOT NULL,
+    date_from DATE NOT NULL,
+    date_to DATE NOT NULL,
+    attempt INT NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    retry_in INT NOT NULL DEFAULT 0,
+    units_spent INT NOT NULL DEFAULT 0,
+    units_left INT NOT NULL DEFAULT 0,
+    units_limit INT NOT NULL DEFAULT 0,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    file_path VARCHAR(1024) NOT NULL DEFAULT '',
+    error TEXT NULL,
+    created_at DATETIME NOT NULL,
+    KEY idx_ydloader_attempts_run_id (run_id),
+    KEY idx_ydloader_attempts_login (login, created_at),
+    CONSTRAINT fk_ydloader_attempts_run_id FOREIGN KEY (run_id) REFERENCES ydloader_runs (id) ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS ydloader_tokens (
+    integration_id BIGINT NOT NULL PRIMARY KEY,
+    status VARCHAR(16) NOT NULL DEFAULT 'valid',
+    reason TEXT NULL,
+    invalid_since DATETIME NULL,
+    access_token TEXT NULL,
+    refresh_token TEXT NULL,
+    expires_at DATETIME NULL,
+    updated_at DATETIME NOT NULL
+);
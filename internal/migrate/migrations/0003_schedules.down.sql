@@ -0,0 +1,3 @@
+ALTER TABLE ydloader_runs DROP COLUMN report;
+
+DROP TABLE IF EXISTS ydloader_schedules;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS ydloader_schedules (
+    report VARCHAR(255) NOT NULL PRIMARY KEY,
+    last_run_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+ALTER TABLE ydloader_runs ADD COLUMN report VARCHAR(255) NOT NULL DEFAULT '' AFTER id;
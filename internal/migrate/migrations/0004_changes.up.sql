@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS ydloader_changes (
+    report VARCHAR(255) NOT NULL,
+    login VARCHAR(255) NOT NULL,
+    checked_at VARCHAR(32) NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (report, login)
+);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS ydloader_goals (
+    integration_id BIGINT NOT NULL,
+    goal_id BIGINT NOT NULL,
+    PRIMARY KEY (integration_id, goal_id)
+);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS ydloader_timezones (
+    login VARCHAR(255) NOT NULL PRIMARY KEY,
+    timezone VARCHAR(64) NOT NULL,
+    updated_at DATETIME NOT NULL
+);
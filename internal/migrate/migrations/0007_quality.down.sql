@@ -0,0 +1,3 @@
+ALTER TABLE ydloader_runs DROP COLUMN quality_failed;
+
+DROP TABLE IF EXISTS ydloader_quality;
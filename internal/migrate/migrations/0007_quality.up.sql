@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS ydloader_quality (
+    report VARCHAR(255) NOT NULL,
+    login VARCHAR(255) NOT NULL,
+    date DATE NOT NULL,
+    totals TEXT NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (report, login, date)
+);
+
+ALTER TABLE ydloader_runs ADD COLUMN quality_failed INT NOT NULL DEFAULT 0 AFTER jobs_failed;
@@ -0,0 +1,10 @@
+DELETE FROM ydloader_quality WHERE environment <> 'production';
+ALTER TABLE ydloader_quality DROP PRIMARY KEY, ADD PRIMARY KEY (report, login, date), DROP COLUMN environment;
+
+DELETE FROM ydloader_schedules WHERE environment <> 'production';
+ALTER TABLE ydloader_schedules DROP PRIMARY KEY, ADD PRIMARY KEY (report), DROP COLUMN environment;
+
+DELETE FROM ydloader_changes WHERE environment <> 'production';
+ALTER TABLE ydloader_changes DROP PRIMARY KEY, ADD PRIMARY KEY (report, login), DROP COLUMN environment;
+
+ALTER TABLE ydloader_runs DROP COLUMN environment;
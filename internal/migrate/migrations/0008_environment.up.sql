@@ -0,0 +1,10 @@
+ALTER TABLE ydloader_runs ADD COLUMN environment VARCHAR(16) NOT NULL DEFAULT 'production' AFTER report;
+
+ALTER TABLE ydloader_changes ADD COLUMN environment VARCHAR(16) NOT NULL DEFAULT 'production' FIRST,
+    DROP PRIMARY KEY, ADD PRIMARY KEY (environment, report, login);
+
+ALTER TABLE ydloader_schedules ADD COLUMN environment VARCHAR(16) NOT NULL DEFAULT 'production' FIRST,
+    DROP PRIMARY KEY, ADD PRIMARY KEY (environment, report);
+
+ALTER TABLE ydloader_quality ADD COLUMN environment VARCHAR(16) NOT NULL DEFAULT 'production' FIRST,
+    DROP PRIMARY KEY, ADD PRIMARY KEY (environment, report, login, date);
@@ -0,0 +1,103 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package money
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/report"
+)
+
+// Money fields of reports, goal columns of fields are money columns too
+var Fields = []string{"AvgCpc", "AvgCpm", "AvgEffectiveBid", "Cost", "CostPerConversion", "Profit", "Revenue"}
+
+// Money fields including VAT if report request has IncludeVAT YES
+var VatFields = []string{"AvgCpc", "AvgCpm", "AvgEffectiveBid", "Cost", "CostPerConversion"}
+
+// Added columns of money table
+const (
+	ColumnCurrency        = "Currency"
+	ColumnAccountCurrency = "AccountCurrency"
+	ColumnRate            = "Rate"
+	ColumnVatRate         = "VatRate"
+	SuffixWithVat         = "_WithVAT"
+	SuffixWithoutVat      = "_WithoutVAT"
+)
+
+// Options define money conversion of report
+type Options struct {
+	Micros          bool    // Money values are in micros
+	IncludeVat      bool    // VAT fields include VAT
+	VatRate         Decimal // VAT rate of account, percent
+	AccountCurrency string  // Currency of report values
+	Currency        string  // Reporting currency
+	Rate            Decimal // Rate of account currency to reporting currency
+}
+
+// Returns money field of column, empty if column is not money column
+func columnField(column string) string {
+	if goal, ok := report.ParseGoalColumn(column); ok {
+		column = goal.Field
+	}
+	if slices.Contains(Fields, column) {
+		return column
+	}
+	return ""
+}
+
+// Convert returns money table: money values are decimals in account currency,
+// every money column has <column>_WithVAT and <column>_WithoutVAT values in reporting currency,
+// currency, account currency, rate and VAT rate columns are added. Empty values ("--") are kept
+func Convert(table *report.Table, opts Options) (*report.Table, error) {
+	hundred := FromMicros(100 * microsPerUnit)
+	vatFactor := hundred.Add(opts.VatRate).Div(hundred)
+	result := &report.Table{Columns: slices.Clone(table.Columns)}
+	moneyColumns := []int{}
+	for i, column := range table.Columns {
+		if columnField(column) != "" {
+			moneyColumns = append(moneyColumns, i)
+			result.Columns = append(result.Columns, column+SuffixWithVat, column+SuffixWithoutVat)
+		}
+	}
+	result.Columns = append(result.Columns, ColumnCurrency, ColumnAccountCurrency, ColumnRate, ColumnVatRate)
+	for n, row := range table.Rows {
+		resultRow := slices.Clone(row)
+		for _, i := range moneyColumns {
+			if row[i] == "--" {
+				resultRow = append(resultRow, "--", "--")
+				continue
+			}
+			value, err := parseValue(row[i], opts.Micros)
+			if err != nil {
+				return nil, fmt.Errorf("row %d, %s: %w", n+1, table.Columns[i], err)
+			}
+			resultRow[i] = value.String()
+			withVat, withoutVat := value, value
+			if slices.Contains(VatFields, columnField(table.Columns[i])) {
+				if opts.IncludeVat {
+					withoutVat = value.Div(vatFactor)
+				} else {
+					withVat = value.Mul(vatFactor)
+				}
+			}
+			resultRow = append(resultRow, withVat.Mul(opts.Rate).String(), withoutVat.Mul(opts.Rate).String())
+		}
+		resultRow = append(resultRow, opts.Currency, opts.AccountCurrency, opts.Rate.String(), opts.VatRate.String())
+		result.Rows = append(result.Rows, resultRow)
+	}
+	return result, nil
+}
+
+// Returns decimal of report money value
+func parseValue(value string, micros bool) (Decimal, error) {
+	if !micros {
+		return Parse(value)
+	}
+	amount, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("invalid micros %q", value)
+	}
+	return FromMicros(amount), nil
+}
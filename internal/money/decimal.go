@@ -0,0 +1,102 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for working with money values of reports:
+// decimal money type, currency conversion and VAT normalization.
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Micros in unit
+const microsPerUnit = 1000000
+
+// Decimal define money value with 6 decimal places, stored in micros
+type Decimal struct {
+	micros int64
+}
+
+// Returns decimal of micros
+func FromMicros(micros int64) Decimal {
+	return Decimal{micros: micros}
+}
+
+// Parse returns decimal of string, e.g. "123.45", "-0.5", "100"
+func Parse(value string) (Decimal, error) {
+	value = strings.TrimSpace(value)
+	sign := int64(1)
+	digits := value
+	if rest, ok := strings.CutPrefix(digits, "-"); ok {
+		sign = -1
+		digits = rest
+	}
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole == "" || len(fraction) > 6 || strings.ContainsAny(whole+fraction, "+-") {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", value)
+	}
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", value)
+	}
+	var micros int64
+	if fraction != "" {
+		micros, err = strconv.ParseInt(fraction+strings.Repeat("0", 6-len(fraction)), 10, 64)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", value)
+		}
+	}
+	return Decimal{micros: sign * (units*microsPerUnit + micros)}, nil
+}
+
+// Micros returns value in micros
+func (d Decimal) Micros() int64 {
+	return d.micros
+}
+
+// Add returns sum of decimals
+func (d Decimal) Add(other Decimal) Decimal {
+	return Decimal{micros: d.micros + other.micros}
+}
+
+// Mul returns product of decimals rounded half away from zero
+func (d Decimal) Mul(other Decimal) Decimal {
+	product := new(big.Int).Mul(big.NewInt(d.micros), big.NewInt(other.micros))
+	return Decimal{micros: roundDiv(product, big.NewInt(microsPerUnit))}
+}
+
+// Div returns quotient of decimals rounded half away from zero, other must not be zero
+func (d Decimal) Div(other Decimal) Decimal {
+	dividend := new(big.Int).Mul(big.NewInt(d.micros), big.NewInt(microsPerUnit))
+	return Decimal{micros: roundDiv(dividend, big.NewInt(other.micros))}
+}
+
+// Returns a / b rounded half away from zero
+func roundDiv(a *big.Int, b *big.Int) int64 {
+	quotient, remainder := new(big.Int).QuoRem(a, b, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(new(big.Int).Abs(b)) >= 0 {
+		if (a.Sign() < 0) != (b.Sign() < 0) {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return quotient.Int64()
+}
+
+// String returns decimal with at least 2 decimal places, e.g. "123.45", "0.123456"
+func (d Decimal) String() string {
+	micros := d.micros
+	sign := ""
+	if micros < 0 {
+		sign = "-"
+		micros = -micros
+	}
+	fraction := strings.TrimRight(fmt.Sprintf("%06d", micros%microsPerUnit), "0")
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+	return sign + strconv.FormatInt(micros/microsPerUnit, 10) + "." + fraction
+}
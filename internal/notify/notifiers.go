@@ -0,0 +1,153 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default Telegram bot API address
+const DefaultTelegramUrl = "https://api.telegram.org"
+
+// Post JSON to url, returns error if response status is not 2xx
+func postJson(ctx context.Context, client *http.Client, url string, token string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Webhook define notifier posting JSON payload: {"subject": "...", "text": "...", "event": {...}}
+type Webhook struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// Returns new webhook notifier, token is sent as bearer token if set
+func NewWebhook(url string, token string) *Webhook {
+	return &Webhook{url: url, token: token, client: &http.Client{}}
+}
+
+// Name returns notifier name
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+// Notify post message
+func (w *Webhook) Notify(ctx context.Context, msg Message) error {
+	payload := map[string]any{"subject": msg.Subject, "text": msg.Text, "event": msg.Event}
+	return postJson(ctx, w.client, w.url, w.token, payload)
+}
+
+// Telegram define notifier sending messages by Telegram bot API sendMessage method
+type Telegram struct {
+	url    string
+	token  string
+	chatId string
+	client *http.Client
+}
+
+// Returns new Telegram notifier, DefaultTelegramUrl is used if url is empty
+func NewTelegram(url string, token string, chatId string) *Telegram {
+	if url == "" {
+		url = DefaultTelegramUrl
+	}
+	return &Telegram{url: strings.TrimRight(url, "/"), token: token, chatId: chatId, client: &http.Client{}}
+}
+
+// Name returns notifier name
+func (t *Telegram) Name() string {
+	return "telegram"
+}
+
+// Notify send message to chat
+func (t *Telegram) Notify(ctx context.Context, msg Message) error {
+	payload := map[string]any{"chat_id": t.chatId, "text": msg.Subject + "\n" + msg.Text}
+	err := postJson(ctx, t.client, t.url+"/bot"+t.token+"/sendMessage", "", payload)
+	if err != nil && t.token != "" {
+		// Bot token is a part of the url, hide it in errors
+		return errors.New(strings.ReplaceAll(err.Error(), t.token, "****"))
+	}
+	return err
+}
+
+// Smtp define notifier sending email
+type Smtp struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// Returns new SMTP notifier, PLAIN auth is used if username is set
+func NewSmtp(host string, port int, username string, password string, from string, to []string) *Smtp {
+	return &Smtp{host: host, port: port, username: username, password: password, from: from, to: to}
+}
+
+// Name returns notifier name
+func (s *Smtp) Name() string {
+	return "smtp"
+}
+
+// Notify send email, STARTTLS is used if server supports it
+func (s *Smtp) Notify(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+	body := strings.Join([]string{
+		"From: " + s.from,
+		"To: " + strings.Join(s.to, ", "),
+		// Report names and errors may be not ASCII
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		msg.Text,
+	}, "\r\n")
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, s.from, s.to, []byte(body))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
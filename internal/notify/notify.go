@@ -0,0 +1,249 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for notifications.
+// Notifications are sent on run end and on permanent job failures by webhook, SMTP or Telegram bot API.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Event kinds
+const (
+	EventRun       = "run"        // Run finished
+	EventRunFailed = "run_failed" // Run finished with failed jobs or failed quality checks
+	EventJobFailed = "job_failed" // Job failed permanently
+)
+
+// Event define notification event data, available in message templates
+type Event struct {
+	Kind          string    `json:"kind"`
+	Time          time.Time `json:"time"`
+	Report        string    `json:"report"`
+	RunId         int64     `json:"run_id,omitempty"`
+	Status        string    `json:"status,omitempty"` // Run status
+	JobsTotal     int       `json:"jobs_total,omitempty"`
+	JobsSucceeded int       `json:"jobs_succeeded,omitempty"`
+	JobsFailed    int       `json:"jobs_failed,omitempty"`
+	QualityFailed int       `json:"quality_failed,omitempty"` // Jobs with failed data quality rules
+	Login         string    `json:"login,omitempty"`          // Failed job login
+	DateFrom      string    `json:"date_from,omitempty"`
+	DateTo        string    `json:"date_to,omitempty"`
+	Try           int       `json:"try,omitempty"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// Message define rendered notification
+type Message struct {
+	Subject string
+	Text    string
+	Event   Event
+}
+
+// Notifier define interface for notification channel
+type Notifier interface {
+	// Name returns notifier name for logs
+	Name() string
+	// Notify send message
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Template define message templates in text/template syntax, event fields are available
+type Template struct {
+	Subject string
+	Text    string
+}
+
+// Default templates of run and job events
+var (
+	DefaultRunTemplate = Template{
+		Subject: "ydloader: {{.Report}} run {{.Status}}",
+		Text:    "Report {{.Report}} run {{.RunId}} finished with status {{.Status}}: {{.JobsSucceeded}} of {{.JobsTotal}} jobs succeeded, {{.JobsFailed}} failed.{{if .QualityFailed}} {{.QualityFailed}} jobs failed quality checks.{{end}}",
+	}
+	DefaultJobTemplate = Template{
+		Subject: "ydloader: {{.Report}} job failed for {{.Login}}",
+		Text:    "Report {{.Report}} job for {{.Login}} ({{.DateFrom}}..{{.DateTo}}) failed after {{.Try}} tries: {{.Error}}",
+	}
+)
+
+// Parsed message templates
+type templates struct {
+	subject *template.Template
+	text    *template.Template
+}
+
+// Parse template, empty fields are taken from default template
+func parseTemplate(name string, tmpl Template, def Template) (*templates, error) {
+	if tmpl.Subject == "" {
+		tmpl.Subject = def.Subject
+	}
+	if tmpl.Text == "" {
+		tmpl.Text = def.Text
+	}
+	subject, err := template.New(name + " subject").Parse(tmpl.Subject)
+	if err != nil {
+		return nil, err
+	}
+	text, err := template.New(name + " text").Parse(tmpl.Text)
+	if err != nil {
+		return nil, err
+	}
+	return &templates{subject: subject, text: text}, nil
+}
+
+// Render message for event
+func (t *templates) render(event Event) (Message, error) {
+	subject := bytes.Buffer{}
+	err := t.subject.Execute(&subject, event)
+	if err != nil {
+		return Message{}, err
+	}
+	text := bytes.Buffer{}
+	err = t.text.Execute(&text, event)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Subject: subject.String(), Text: text.String(), Event: event}, nil
+}
+
+// Options define dispatcher options
+type Options struct {
+	Events      []string      // Event kinds to notify
+	RunTemplate Template      // Template of run events, default DefaultRunTemplate
+	JobTemplate Template      // Template of job events, default DefaultJobTemplate
+	RateLimit   int           // Max messages per rate interval, 0 - unlimited
+	RateWindow  time.Duration // Rate interval
+	Timeout     time.Duration // Send timeout of one notifier
+}
+
+// Dispatcher define notifications sender to all notifiers
+type Dispatcher struct {
+	notifiers []Notifier
+	events    []string
+	run       *templates
+	job       *templates
+	limiter   *limiter
+	timeout   time.Duration
+	wg        sync.WaitGroup
+}
+
+// Returns new dispatcher, error if templates are invalid
+func NewDispatcher(notifiers []Notifier, opts Options) (*Dispatcher, error) {
+	run, err := parseTemplate("run", opts.RunTemplate, DefaultRunTemplate)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	job, err := parseTemplate("job", opts.JobTemplate, DefaultJobTemplate)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	return &Dispatcher{
+		notifiers: notifiers,
+		events:    opts.Events,
+		run:       run,
+		job:       job,
+		limiter:   newLimiter(opts.RateLimit, opts.RateWindow),
+		timeout:   opts.Timeout,
+	}, nil
+}
+
+// Enabled returns true if event kind is notified
+func (d *Dispatcher) Enabled(kind string) bool {
+	return len(d.notifiers) > 0 && slices.Contains(d.events, kind)
+}
+
+// Send event to all notifiers in background if event kind is enabled and rate limit is not exceeded.
+// Errors are passed to onError
+func (d *Dispatcher) Send(event Event, onError func(notifier string, err error)) {
+	if !d.Enabled(event.Kind) {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	tmpl := d.run
+	if event.Kind == EventJobFailed {
+		tmpl = d.job
+	}
+	msg, err := tmpl.render(event)
+	if err != nil {
+		onError("template", err)
+		return
+	}
+	allowed, suppressed := d.limiter.allow(event.Time)
+	if !allowed {
+		return
+	}
+	if suppressed > 0 {
+		msg.Text += fmt.Sprintf("\n(%d notifications suppressed by rate limit)", suppressed)
+	}
+	for _, notifier := range d.notifiers {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+			defer cancel()
+			err := notifier.Notify(ctx, msg)
+			if err != nil {
+				onError(notifier.Name(), err)
+			}
+		}()
+	}
+}
+
+// Wait for notifications in progress
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// Limiter define fixed window rate limiter
+type limiter struct {
+	mu         sync.Mutex
+	limit      int
+	window     time.Duration
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// Returns new limiter, limit 0 - unlimited
+func newLimiter(limit int, window time.Duration) *limiter {
+	return &limiter{limit: limit, window: window}
+}
+
+// Returns true if message is allowed and count of messages suppressed before it
+func (l *limiter) allow(now time.Time) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit <= 0 {
+		return true, 0
+	}
+	if now.Sub(l.start) >= l.window {
+		l.start = now
+		l.count = 0
+	}
+	if l.count >= l.limit {
+		l.suppressed++
+		return false, 0
+	}
+	l.count++
+	suppressed := l.suppressed
+	l.suppressed = 0
+	return true, suppressed
+}
+
+// ValidateTemplate returns error if template is not valid text/template
+func ValidateTemplate(tmpl Template) error {
+	_, err := parseTemplate("template", tmpl, Template{Subject: " ", Text: " "})
+	return err
+}
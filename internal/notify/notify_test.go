@@ -0,0 +1,227 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Test event of failed run
+var testEvent = Event{
+	Kind:          EventRunFailed,
+	Report:        "отчёт",
+	RunId:         7,
+	Status:        "partial",
+	JobsTotal:     3,
+	JobsSucceeded: 2,
+	JobsFailed:    1,
+}
+
+// Records requests of test HTTP server
+type recorder struct {
+	mu       sync.Mutex
+	paths    []string
+	auth     []string
+	payloads []map[string]any
+}
+
+// Returns test HTTP server recording JSON requests and responding with status
+func (r *recorder) server(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		payload := map[string]any{}
+		err := json.NewDecoder(req.Body).Decode(&payload)
+		if err != nil {
+			t.Error(err)
+		}
+		r.mu.Lock()
+		r.paths = append(r.paths, req.URL.Path)
+		r.auth = append(r.auth, req.Header.Get("Authorization"))
+		r.payloads = append(r.payloads, payload)
+		r.mu.Unlock()
+		w.WriteHeader(status)
+		w.Write([]byte("response body"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWebhook(t *testing.T) {
+	r := &recorder{}
+	server := r.server(t, http.StatusOK)
+	msg := Message{Subject: "subject", Text: "text", Event: testEvent}
+	err := NewWebhook(server.URL+"/hook", "secret").Notify(context.Background(), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.paths[0] != "/hook" || r.auth[0] != "Bearer secret" {
+		t.Errorf("request %s, authorization %q", r.paths[0], r.auth[0])
+	}
+	payload := r.payloads[0]
+	if payload["subject"] != "subject" || payload["text"] != "text" {
+		t.Errorf("payload = %v", payload)
+	}
+	event, _ := payload["event"].(map[string]any)
+	if event["kind"] != EventRunFailed || event["report"] != "отчёт" || event["jobs_failed"] != float64(1) {
+		t.Errorf("event = %v", event)
+	}
+}
+
+func TestWebhookError(t *testing.T) {
+	r := &recorder{}
+	server := r.server(t, http.StatusBadGateway)
+	err := NewWebhook(server.URL, "").Notify(context.Background(), Message{Event: testEvent})
+	if err == nil || !strings.Contains(err.Error(), "502") || !strings.Contains(err.Error(), "response body") {
+		t.Errorf("error = %v", err)
+	}
+	if r.auth[0] != "" {
+		t.Errorf("authorization %q without token", r.auth[0])
+	}
+}
+
+func TestTelegram(t *testing.T) {
+	r := &recorder{}
+	server := r.server(t, http.StatusOK)
+	msg := Message{Subject: "subject", Text: "text"}
+	err := NewTelegram(server.URL+"/", "123:bot", "-100").Notify(context.Background(), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.paths[0] != "/bot123:bot/sendMessage" {
+		t.Errorf("path = %s", r.paths[0])
+	}
+	if r.payloads[0]["chat_id"] != "-100" || r.payloads[0]["text"] != "subject\ntext" {
+		t.Errorf("payload = %v", r.payloads[0])
+	}
+}
+
+func TestTelegramErrorHidesToken(t *testing.T) {
+	// Closed server: connection error contains request url
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+	err := NewTelegram(url, "123:bot", "-100").Notify(context.Background(), Message{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if strings.Contains(err.Error(), "123:bot") {
+		t.Errorf("error contains bot token: %s", err)
+	}
+}
+
+// Starts stub SMTP server accepting one message, returns its port and received message data
+func smtpServer(t *testing.T) (int, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	data := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		write := func(line string) {
+			io.WriteString(conn, line+"\r\n")
+		}
+		write("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				write("250 localhost")
+			case command == "DATA":
+				write("354 end data with <CR><LF>.<CR><LF>")
+				message := []string{}
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					message = append(message, line)
+				}
+				data <- strings.Join(message, "")
+				write("250 OK")
+			case command == "QUIT":
+				write("221 bye")
+				return
+			default:
+				write("250 OK")
+			}
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port, data
+}
+
+func TestSmtp(t *testing.T) {
+	port, data := smtpServer(t)
+	msg := Message{Subject: "ydloader: отчёт run partial", Text: "Report отчёт run 7 finished"}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := NewSmtp("127.0.0.1", port, "", "", "loader@example.com", []string{"a@example.com", "b@example.com"}).Notify(ctx, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := <-data
+	for _, want := range []string{
+		"From: loader@example.com\r\n",
+		"To: a@example.com, b@example.com\r\n",
+		"Subject: =?utf-8?q?ydloader:_=D0=BE=D1=82=D1=87=D1=91=D1=82_run_partial?=\r\n",
+		"Content-Type: text/plain; charset=UTF-8\r\n",
+		"\r\n\r\nReport отчёт run 7 finished",
+	} {
+		if !strings.Contains(message, want) {
+			t.Errorf("message has no %q:\n%s", want, message)
+		}
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	r := &recorder{}
+	server := r.server(t, http.StatusOK)
+	d, err := NewDispatcher([]Notifier{NewWebhook(server.URL, "")}, Options{
+		Events:     []string{EventRunFailed},
+		RateLimit:  1,
+		RateWindow: time.Hour,
+		Timeout:    5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	onError := func(notifier string, err error) {
+		t.Errorf("%s: %s", notifier, err)
+	}
+	d.Send(Event{Kind: EventRun, Report: "not notified"}, onError)
+	d.Send(testEvent, onError)
+	d.Send(testEvent, onError)
+	d.Wait()
+	if len(r.payloads) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(r.payloads))
+	}
+	want := "Report отчёт run " + strconv.Itoa(int(testEvent.RunId)) + " finished with status partial: 2 of 3 jobs succeeded, 1 failed."
+	if r.payloads[0]["text"] != want {
+		t.Errorf("text = %q, want %q", r.payloads[0]["text"], want)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for OAuth token refresh
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Default Yandex OAuth token endpoint
+const DefaultTokenUrl = "https://oauth.yandex.ru/token"
+
+// Token define OAuth token endpoint response
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	ExpiresAt    time.Time
+}
+
+// Token endpoint error response
+type errorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Client define OAuth client for refresh token flow
+type Client struct {
+	TokenUrl     string
+	ClientId     string
+	ClientSecret string
+	Http         *http.Client
+}
+
+// Returns new OAuth client
+func NewClient(tokenUrl string, clientId string, clientSecret string, timeout time.Duration) *Client {
+	if tokenUrl == "" {
+		tokenUrl = DefaultTokenUrl
+	}
+	return &Client{
+		TokenUrl:     tokenUrl,
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		Http:         &http.Client{Timeout: timeout},
+	}
+}
+
+// Refresh returns new access token for refresh token
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	if refreshToken == "" {
+		return nil, errors.New("refresh token is empty")
+	}
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+	form.Set("client_id", c.ClientId)
+	form.Set("client_secret", c.ClientSecret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.Http.Do(req)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		errResp := errorResponse{}
+		_ = json.Unmarshal(body, &errResp)
+		return nil, fmt.Errorf("token refresh failed: %s %s %s", resp.Status, errResp.Error, errResp.ErrorDescription)
+	}
+	token := &Token{}
+	err = json.Unmarshal(body, token)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("token refresh failed: empty access token")
+	}
+	if token.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
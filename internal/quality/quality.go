@@ -0,0 +1,210 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for data quality checks of loaded reports.
+// Reports are checked by rules of report spec: non-empty, unique keys, numeric sanity and day-over-day deviation.
+package quality
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/money"
+	"github.com/AlekseiGrigorev/ydloader/internal/report"
+)
+
+// Total of row count in daily totals
+const TotalRows = "Rows"
+
+// Default columns of deviation check
+var DefaultColumns = []string{"Impressions", "Clicks", "Cost"}
+
+// Max reported errors of one rule
+const maxRuleErrors = 5
+
+// Rules define data quality rules of report
+type Rules struct {
+	NonEmpty  bool     // Report of active login must have rows
+	Key       []string // Columns of row key, rows with duplicate keys are errors
+	Sanity    bool     // Clicks <= Impressions, money values >= 0
+	Deviation int      // Max day-over-day change of daily totals in percent of reports with Date column, 0 - not checked
+	Columns   []string // Columns of daily totals, DefaultColumns if empty
+}
+
+// Totals define daily totals of report: sums of columns and row count
+type Totals map[string]float64
+
+// Returns columns of daily totals
+func (r Rules) columns() []string {
+	if len(r.Columns) == 0 {
+		return DefaultColumns
+	}
+	return r.Columns
+}
+
+// Returns numeric value of report cell, false for empty values ("--")
+func number(value string) (float64, bool) {
+	result, err := strconv.ParseFloat(value, 64)
+	return result, err == nil
+}
+
+// DailyTotals returns totals of every date from dateFrom to dateTo, dates without rows have zero totals.
+// Reports without Date column have totals of dateTo only
+func DailyTotals(table *report.Table, rules Rules, dateFrom string, dateTo string) (map[string]Totals, error) {
+	from, err := time.Parse(report.DateFormat, dateFrom)
+	if err != nil {
+		return nil, err
+	}
+	to, err := time.Parse(report.DateFormat, dateTo)
+	if err != nil {
+		return nil, err
+	}
+	dateColumn := slices.Index(table.Columns, "Date")
+	daily := map[string]Totals{}
+	if dateColumn < 0 {
+		daily[dateTo] = Totals{}
+	} else {
+		for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+			daily[date.Format(report.DateFormat)] = Totals{}
+		}
+	}
+	for _, row := range table.Rows {
+		date := dateTo
+		if dateColumn >= 0 {
+			date = row[dateColumn]
+		}
+		totals, ok := daily[date]
+		if !ok {
+			totals = Totals{}
+			daily[date] = totals
+		}
+		totals[TotalRows]++
+		for _, column := range rules.columns() {
+			if i := slices.Index(table.Columns, column); i >= 0 {
+				if value, ok := number(row[i]); ok {
+					totals[column] += value
+				}
+			}
+		}
+	}
+	return daily, nil
+}
+
+// Check returns errors of report rules. Daily are totals of report,
+// previous are stored totals of login for the day before report dates, nil if not stored
+func Check(table *report.Table, rules Rules, daily map[string]Totals, previous Totals) error {
+	errs := []error{}
+	if rules.NonEmpty && len(table.Rows) == 0 && previous[TotalRows] > 0 {
+		errs = append(errs, errors.New("non-empty: report is empty, previous report of login has rows"))
+	}
+	if len(rules.Key) > 0 {
+		errs = append(errs, checkKey(table, rules.Key)...)
+	}
+	if rules.Sanity {
+		errs = append(errs, checkSanity(table)...)
+	}
+	// Totals of report without Date column are totals of all report dates, they are not comparable by days
+	if rules.Deviation > 0 && slices.Contains(table.Columns, "Date") {
+		errs = append(errs, checkDeviation(rules, daily, previous)...)
+	}
+	return errors.Join(errs...)
+}
+
+// Returns errors of duplicate row keys
+func checkKey(table *report.Table, key []string) []error {
+	indexes := []int{}
+	for _, column := range key {
+		i := slices.Index(table.Columns, column)
+		if i < 0 {
+			return []error{fmt.Errorf("key: column %s not found", column)}
+		}
+		indexes = append(indexes, i)
+	}
+	errs := []error{}
+	seen := map[string]int{}
+	for n, row := range table.Rows {
+		values := make([]string, 0, len(indexes))
+		for _, i := range indexes {
+			values = append(values, row[i])
+		}
+		value := strings.Join(values, "\t")
+		if first, ok := seen[value]; ok && len(errs) < maxRuleErrors {
+			errs = append(errs, fmt.Errorf("key: rows %d and %d have same key %s", first, n+1, strings.Join(values, ", ")))
+		}
+		seen[value] = n + 1
+	}
+	return errs
+}
+
+// Returns errors of clicks greater than impressions and negative money values
+func checkSanity(table *report.Table) []error {
+	errs := []error{}
+	add := func(format string, args ...any) {
+		if len(errs) < maxRuleErrors {
+			errs = append(errs, fmt.Errorf("sanity: "+format, args...))
+		}
+	}
+	clicks := slices.Index(table.Columns, "Clicks")
+	impressions := slices.Index(table.Columns, "Impressions")
+	for n, row := range table.Rows {
+		if clicks >= 0 && impressions >= 0 {
+			c, okClicks := number(row[clicks])
+			i, okImpressions := number(row[impressions])
+			if okClicks && okImpressions && c > i {
+				add("row %d: clicks %s greater than impressions %s", n+1, row[clicks], row[impressions])
+			}
+		}
+		for i, column := range table.Columns {
+			field := column
+			if goal, ok := report.ParseGoalColumn(column); ok {
+				field = goal.Field
+			}
+			// Profit may be negative
+			if field == "Profit" || !slices.Contains(money.Fields, field) {
+				continue
+			}
+			if value, ok := number(row[i]); ok && value < 0 {
+				add("row %d: %s is negative: %s", n+1, column, row[i])
+			}
+		}
+	}
+	return errs
+}
+
+// Returns errors of daily totals changed more than deviation percent from previous day
+func checkDeviation(rules Rules, daily map[string]Totals, previous Totals) []error {
+	errs := []error{}
+	prev := previous
+	for _, date := range sortedDates(daily) {
+		if prev == nil {
+			prev = daily[date]
+			continue
+		}
+		for _, column := range rules.columns() {
+			if prev[column] == 0 {
+				continue
+			}
+			change := math.Abs(daily[date][column]-prev[column]) / prev[column] * 100
+			if change > float64(rules.Deviation) {
+				errs = append(errs, fmt.Errorf("deviation: %s %s changed by %.0f%% from previous day (%g -> %g)",
+					column, date, change, prev[column], daily[date][column]))
+			}
+		}
+		prev = daily[date]
+	}
+	return errs
+}
+
+// Returns dates of daily totals in ascending order
+func sortedDates(daily map[string]Totals) []string {
+	dates := make([]string, 0, len(daily))
+	for date := range daily {
+		dates = append(dates, date)
+	}
+	slices.Sort(dates)
+	return dates
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package quality
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/report"
+)
+
+func TestCheck(t *testing.T) {
+	columns := []string{"Date", "CampaignId", "Impressions", "Clicks", "Cost"}
+	tests := []struct {
+		name     string
+		columns  []string
+		rows     [][]string
+		rules    Rules
+		dateFrom string
+		dateTo   string
+		previous Totals
+		want     []string // Error substrings, no errors if empty
+	}{
+		{
+			name:    "valid report",
+			columns: columns,
+			rows: [][]string{
+				{"2024-05-01", "1", "100", "10", "5.5"},
+				{"2024-05-02", "1", "110", "11", "6"},
+			},
+			rules:    Rules{NonEmpty: true, Key: []string{"Date", "CampaignId"}, Sanity: true, Deviation: 50},
+			dateFrom: "2024-05-01", dateTo: "2024-05-02",
+			previous: Totals{TotalRows: 1, "Impressions": 90, "Clicks": 9, "Cost": 5},
+		},
+		{
+			name:     "empty report of active login",
+			columns:  columns,
+			rules:    Rules{NonEmpty: true},
+			dateFrom: "2024-05-01", dateTo: "2024-05-01",
+			previous: Totals{TotalRows: 2},
+			want:     []string{"non-empty"},
+		},
+		{
+			name:     "empty report of inactive login",
+			columns:  columns,
+			rules:    Rules{NonEmpty: true},
+			dateFrom: "2024-05-01", dateTo: "2024-05-01",
+			previous: Totals{TotalRows: 0},
+		},
+		{
+			name:    "duplicate keys and sanity",
+			columns: columns,
+			rows: [][]string{
+				{"2024-05-01", "1", "100", "10", "5"},
+				{"2024-05-01", "1", "10", "20", "-1"},
+			},
+			rules:    Rules{Key: []string{"Date", "CampaignId"}, Sanity: true},
+			dateFrom: "2024-05-01", dateTo: "2024-05-01",
+			want: []string{"key: rows 1 and 2", "clicks 20 greater than impressions 10", "Cost is negative"},
+		},
+		{
+			name:     "unknown key column",
+			columns:  columns,
+			rows:     [][]string{{"2024-05-01", "1", "100", "10", "5"}},
+			rules:    Rules{Key: []string{"AdId"}},
+			dateFrom: "2024-05-01", dateTo: "2024-05-01",
+			want: []string{"column AdId not found"},
+		},
+		{
+			name:    "deviation from report and stored totals",
+			columns: columns,
+			rows: [][]string{
+				{"2024-05-01", "1", "100", "10", "5"},
+				{"2024-05-02", "1", "300", "10", "5"},
+			},
+			rules:    Rules{Deviation: 50, Columns: []string{"Impressions"}},
+			dateFrom: "2024-05-01", dateTo: "2024-05-02",
+			previous: Totals{"Impressions": 1000},
+			want:     []string{"Impressions 2024-05-01 changed by 90%", "Impressions 2024-05-02 changed by 200%"},
+		},
+		{
+			name:     "deviation is not checked without Date column",
+			columns:  []string{"CampaignId", "Impressions"},
+			rows:     [][]string{{"1", "7000"}},
+			rules:    Rules{Deviation: 10},
+			dateFrom: "2024-05-01", dateTo: "2024-05-07",
+			previous: Totals{"Impressions": 1000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &report.Table{Columns: tt.columns, Rows: tt.rows}
+			daily, err := DailyTotals(table, tt.rules, tt.dateFrom, tt.dateTo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = Check(table, tt.rules, daily, tt.previous)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("unexpected errors: %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors %q", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("errors have no %q:\n%s", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDailyTotals(t *testing.T) {
+	table := &report.Table{
+		Columns: []string{"Date", "Clicks"},
+		Rows:    [][]string{{"2024-05-01", "2"}, {"2024-05-01", "3"}, {"2024-05-03", "--"}},
+	}
+	daily, err := DailyTotals(table, Rules{Columns: []string{"Clicks"}}, "2024-05-01", "2024-05-03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Totals{
+		"2024-05-01": {TotalRows: 2, "Clicks": 5},
+		"2024-05-02": {},
+		"2024-05-03": {TotalRows: 1},
+	}
+	if len(daily) != len(want) {
+		t.Fatalf("daily = %v, want %v", daily, want)
+	}
+	for date, totals := range want {
+		for column, value := range totals {
+			if daily[date][column] != value {
+				t.Errorf("%s %s = %g, want %g", date, column, daily[date][column], value)
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package quality
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/db"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Datetime format for quality table
+const timeFormat = "2006-01-02 15:04:05"
+
+// Row define stored daily totals of report login
+type Row struct {
+	Totals string `db:"totals"`
+}
+
+// Store define daily totals store of environment in database, totals are used by non-empty and deviation rules
+type Store struct {
+	db          *db.Db
+	environment string
+}
+
+// Returns new daily totals store of environment: production or sandbox
+func NewStore(dbIn *db.Db, environment string) *Store {
+	return &Store{db: dbIn, environment: environment}
+}
+
+// Totals returns stored totals of report login date, nil if totals are not stored
+func (s *Store) Totals(ctx context.Context, report string, login string, date string) (Totals, error) {
+	query := "SELECT totals FROM ydloader_quality WHERE environment = ? AND report = ? AND login = ? AND date = ?"
+	row, err := db.QueryOne[Row](ctx, s.db, query, []any{s.environment, report, login, date})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	totals := Totals{}
+	err = json.Unmarshal([]byte(row.Totals), &totals)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	return totals, nil
+}
+
+// Save store daily totals of report login
+func (s *Store) Save(ctx context.Context, report string, login string, daily map[string]Totals) error {
+	sql := []string{
+		"INSERT INTO ydloader_quality (environment, report, login, date, totals, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		"ON DUPLICATE KEY UPDATE totals = VALUES(totals), updated_at = VALUES(updated_at)",
+	}
+	now := time.Now().UTC().Format(timeFormat)
+	for _, date := range sortedDates(daily) {
+		totals, err := json.Marshal(daily[date])
+		if err != nil {
+			fmt.Println(err, trace.GetTrace())
+			return err
+		}
+		params := []any{s.environment, report, login, date, string(totals), now}
+		_, err = s.db.ExecContext(ctx, strings.Join(sql, " "), params)
+		if err != nil {
+			fmt.Println(err, trace.GetTrace())
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for watching files changes.
+// Watcher watches files and directories and calls handler with changed files after debounce delay.
+package reload
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Handler is called with changed files paths
+type Handler func(changed []string)
+
+// Watcher watches files and directories changes
+type Watcher struct {
+	watcher  *fsnotify.Watcher
+	files    map[string]bool // Watched files
+	dirs     map[string]bool // Watched directories, all files are watched
+	debounce time.Duration
+	handler  Handler
+}
+
+// Returns new watcher for files and directories.
+// Parent directory of every file is watched, so files replaced by editors are tracked too
+func New(paths []string, debounce time.Duration, handler Handler) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	w := &Watcher{
+		watcher:  fsWatcher,
+		files:    map[string]bool{},
+		dirs:     map[string]bool{},
+		debounce: debounce,
+		handler:  handler,
+	}
+	watched := map[string]bool{}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			fsWatcher.Close()
+			return nil, err
+		}
+		dir := abs
+		info, err := os.Stat(abs)
+		if err != nil {
+			fsWatcher.Close()
+			return nil, err
+		}
+		if info.IsDir() {
+			w.dirs[abs] = true
+		} else {
+			w.files[abs] = true
+			dir = filepath.Dir(abs)
+		}
+		if watched[dir] {
+			continue
+		}
+		err = fsWatcher.Add(dir)
+		if err != nil {
+			fsWatcher.Close()
+			fmt.Println(err, trace.GetTrace())
+			return nil, err
+		}
+		watched[dir] = true
+	}
+	return w, nil
+}
+
+// Returns true if file is watched
+func (w *Watcher) watches(path string) bool {
+	return w.files[path] || w.dirs[filepath.Dir(path)]
+}
+
+// Run watch files until context is done
+func (w *Watcher) Run(ctx context.Context) {
+	defer w.watcher.Close()
+	changed := map[string]bool{}
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) && !event.Has(fsnotify.Remove) {
+				continue
+			}
+			path, err := filepath.Abs(event.Name)
+			if err != nil || !w.watches(path) {
+				continue
+			}
+			changed[path] = true
+			timer.Reset(w.debounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Println(err, trace.GetTrace())
+		case <-timer.C:
+			paths := make([]string, 0, len(changed))
+			for path := range changed {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			changed = map[string]bool{}
+			w.handler(paths)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Prefix of report names made by Fingerprint
+const reportNamePrefix = "ydl-"
+
+// Edit rendered report request body: edit function changes params of request.
+// Fields unknown to the schema are kept
+func editParams(body string, edit func(params map[string]any)) (string, error) {
+	request := map[string]any{}
+	// Numbers are kept as is, ids may exceed float64 precision
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	err := decoder.Decode(&request)
+	if err != nil {
+		return "", fmt.Errorf("invalid report request: %w", err)
+	}
+	params, ok := request["params"].(map[string]any)
+	if !ok {
+		return "", errors.New("invalid report request: params must be object")
+	}
+	edit(params)
+	result, err := json.MarshalIndent(request, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// Returns params object field, field is created if not exists
+func object(params map[string]any, key string) map[string]any {
+	value, ok := params[key].(map[string]any)
+	if !ok {
+		value = map[string]any{}
+		params[key] = value
+	}
+	return value
+}
+
+// SetDates returns report request body with CUSTOM_DATE date range from dateFrom to dateTo
+func SetDates(body string, dateFrom string, dateTo string) (string, error) {
+	return editParams(body, func(params map[string]any) {
+		params["DateRangeType"] = "CUSTOM_DATE"
+		criteria := object(params, "SelectionCriteria")
+		criteria["DateFrom"] = dateFrom
+		criteria["DateTo"] = dateTo
+	})
+}
+
+// AddFilter returns report request body with filter added to selection criteria
+func AddFilter(body string, filter Filter) (string, error) {
+	return editParams(body, func(params map[string]any) {
+		criteria := object(params, "SelectionCriteria")
+		filters, _ := criteria["Filter"].([]any)
+		criteria["Filter"] = append(filters, filter)
+	})
+}
+
+// SetGoals returns report request body with goals and attribution models
+func SetGoals(body string, goals []int64, attributionModels []string) (string, error) {
+	return editParams(body, func(params map[string]any) {
+		params["Goals"] = goals
+		if len(attributionModels) > 0 {
+			params["AttributionModels"] = attributionModels
+		}
+	})
+}
+
+// SetReportName returns report request body with report name
+func SetReportName(body string, name string) (string, error) {
+	return editParams(body, func(params map[string]any) {
+		params["ReportName"] = name
+	})
+}
+
+// Fingerprint returns report name of login report request: hash of login, request params without report name
+// and headers changing report content. Identical requests have identical names, so API reuses built reports
+func Fingerprint(login string, body string, headers map[string]string) (string, error) {
+	params, err := editParams(body, func(params map[string]any) {
+		delete(params, "ReportName")
+	})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	hash.Write([]byte(login + "\n" + params + "\n"))
+	for _, name := range booleanHeaders {
+		hash.Write([]byte(name + "=" + headers[name] + "\n"))
+	}
+	return reportNamePrefix + hex.EncodeToString(hash.Sum(nil))[:40], nil
+}
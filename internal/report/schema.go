@@ -0,0 +1,209 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for Yandex Direct reports:
+// report definition schema and validation of report requests.
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Report date format
+const DateFormat = "2006-01-02"
+
+// Allowed report types
+var ReportTypes = []string{
+	"ACCOUNT_PERFORMANCE_REPORT",
+	"CAMPAIGN_PERFORMANCE_REPORT",
+	"ADGROUP_PERFORMANCE_REPORT",
+	"AD_PERFORMANCE_REPORT",
+	"CRITERIA_PERFORMANCE_REPORT",
+	"CUSTOM_REPORT",
+	"REACH_AND_FREQUENCY_PERFORMANCE_REPORT",
+	"SEARCH_QUERY_PERFORMANCE_REPORT",
+}
+
+// Allowed date range types
+var DateRangeTypes = []string{
+	"TODAY", "YESTERDAY", "LAST_3_DAYS", "LAST_5_DAYS", "LAST_7_DAYS", "LAST_14_DAYS", "LAST_30_DAYS",
+	"LAST_90_DAYS", "LAST_365_DAYS", "THIS_WEEK_MON_TODAY", "THIS_WEEK_SUN_TODAY", "LAST_WEEK",
+	"LAST_BUSINESS_WEEK", "LAST_WEEK_SUN_SAT", "THIS_MONTH", "LAST_MONTH", "ALL_TIME", "CUSTOM_DATE", "AUTO",
+}
+
+// Allowed filter operators
+var FilterOperators = []string{
+	"EQUALS", "NOT_EQUALS", "IN", "NOT_IN", "LESS_THAN", "GREATER_THAN",
+	"STARTS_WITH_IGNORE_CASE", "DOES_NOT_START_WITH_IGNORE_CASE",
+	"STARTS_WITH_ANY_IGNORE_CASE", "DOES_NOT_START_WITH_ALL_IGNORE_CASE",
+}
+
+// Allowed attribution models
+var AttributionModels = []string{"FC", "LC", "LSC", "LYDC", "FCCD", "LSCCD", "LYDCCD", "AUTO"}
+
+// Header values
+var processingModes = []string{"auto", "online", "offline"}
+var booleanHeaders = []string{"skipReportHeader", "skipColumnHeader", "skipReportSummary", "returnMoneyInMicros"}
+
+// Filter define selection criteria filter
+type Filter struct {
+	Field    string   `json:"Field"`
+	Operator string   `json:"Operator"`
+	Values   []string `json:"Values"`
+}
+
+// SelectionCriteria define report selection criteria
+type SelectionCriteria struct {
+	DateFrom string   `json:"DateFrom,omitempty"`
+	DateTo   string   `json:"DateTo,omitempty"`
+	Filter   []Filter `json:"Filter,omitempty"`
+}
+
+// Page define report rows limit and offset
+type Page struct {
+	Limit  int64 `json:"Limit"`
+	Offset int64 `json:"Offset,omitempty"`
+}
+
+// OrderBy define report sort field
+type OrderBy struct {
+	Field     string `json:"Field"`
+	SortOrder string `json:"SortOrder,omitempty"`
+}
+
+// Definition define report definition: params of report request
+type Definition struct {
+	SelectionCriteria SelectionCriteria `json:"SelectionCriteria"`
+	Goals             []int64           `json:"Goals,omitempty"`
+	AttributionModels []string          `json:"AttributionModels,omitempty"`
+	FieldNames        []string          `json:"FieldNames"`
+	Page              *Page             `json:"Page,omitempty"`
+	OrderBy           []OrderBy         `json:"OrderBy,omitempty"`
+	ReportName        string            `json:"ReportName"`
+	ReportType        string            `json:"ReportType"`
+	DateRangeType     string            `json:"DateRangeType"`
+	Format            string            `json:"Format"`
+	IncludeVAT        string            `json:"IncludeVAT"`
+	IncludeDiscount   string            `json:"IncludeDiscount,omitempty"`
+}
+
+// Request define report request body
+type Request struct {
+	Params Definition `json:"params"`
+}
+
+// ParseRequest returns report request from body JSON, unknown fields are errors
+func ParseRequest(body []byte) (*Request, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	req := &Request{}
+	err := decoder.Decode(req)
+	if err != nil {
+		return nil, fmt.Errorf("invalid report request: %w", err)
+	}
+	return req, nil
+}
+
+// Validate returns all errors of report definition
+func (d *Definition) Validate() error {
+	errs := []error{}
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	oneOf := func(field string, value string, allowed []string) {
+		if !slices.Contains(allowed, value) {
+			add("%s: must be one of %s, got %q", field, strings.Join(allowed, ", "), value)
+		}
+	}
+	if strings.TrimSpace(d.ReportName) == "" {
+		add("ReportName: must be set")
+	}
+	oneOf("ReportType", d.ReportType, ReportTypes)
+	oneOf("DateRangeType", d.DateRangeType, DateRangeTypes)
+	oneOf("Format", d.Format, []string{"TSV"})
+	oneOf("IncludeVAT", d.IncludeVAT, []string{"YES", "NO"})
+	if d.IncludeDiscount != "" {
+		oneOf("IncludeDiscount", d.IncludeDiscount, []string{"YES", "NO"})
+	}
+	if len(d.FieldNames) == 0 {
+		add("FieldNames: must be set")
+	}
+	seen := map[string]bool{}
+	for _, name := range d.FieldNames {
+		if strings.TrimSpace(name) == "" {
+			add("FieldNames: empty field name")
+		} else if seen[name] {
+			add("FieldNames: duplicate field %q", name)
+		}
+		seen[name] = true
+	}
+	criteria := d.SelectionCriteria
+	if d.DateRangeType == "CUSTOM_DATE" {
+		from, errFrom := time.Parse(DateFormat, criteria.DateFrom)
+		if errFrom != nil {
+			add("SelectionCriteria.DateFrom: must be YYYY-MM-DD for CUSTOM_DATE, got %q", criteria.DateFrom)
+		}
+		to, errTo := time.Parse(DateFormat, criteria.DateTo)
+		if errTo != nil {
+			add("SelectionCriteria.DateTo: must be YYYY-MM-DD for CUSTOM_DATE, got %q", criteria.DateTo)
+		}
+		if errFrom == nil && errTo == nil && from.After(to) {
+			add("SelectionCriteria: DateFrom %s is after DateTo %s", criteria.DateFrom, criteria.DateTo)
+		}
+	} else if criteria.DateFrom != "" || criteria.DateTo != "" {
+		add("SelectionCriteria: DateFrom and DateTo are allowed only for CUSTOM_DATE")
+	}
+	for i, filter := range criteria.Filter {
+		if filter.Field == "" {
+			add("SelectionCriteria.Filter[%d].Field: must be set", i)
+		}
+		oneOf(fmt.Sprintf("SelectionCriteria.Filter[%d].Operator", i), filter.Operator, FilterOperators)
+		if len(filter.Values) == 0 {
+			add("SelectionCriteria.Filter[%d].Values: must be set", i)
+		}
+	}
+	if len(d.Goals) > 10 {
+		add("Goals: max 10 goals, got %d", len(d.Goals))
+	}
+	if len(d.AttributionModels) > 0 && len(d.Goals) == 0 {
+		add("AttributionModels: require Goals")
+	}
+	for _, model := range d.AttributionModels {
+		oneOf("AttributionModels", model, AttributionModels)
+	}
+	if d.Page != nil && d.Page.Limit <= 0 {
+		add("Page.Limit: must be positive, got %d", d.Page.Limit)
+	}
+	for i, order := range d.OrderBy {
+		if order.Field == "" {
+			add("OrderBy[%d].Field: must be set", i)
+		}
+		if order.SortOrder != "" {
+			oneOf(fmt.Sprintf("OrderBy[%d].SortOrder", i), order.SortOrder, []string{"ASCENDING", "DESCENDING"})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateHeaders returns all errors of report request headers
+func ValidateHeaders(headers map[string]string) error {
+	errs := []error{}
+	token, ok := strings.CutPrefix(headers["Authorization"], "Bearer ")
+	if !ok || strings.TrimSpace(token) == "" {
+		errs = append(errs, errors.New("Authorization: must be \"Bearer <token>\""))
+	}
+	if mode, ok := headers["processingMode"]; ok && !slices.Contains(processingModes, mode) {
+		errs = append(errs, fmt.Errorf("processingMode: must be one of %s, got %q", strings.Join(processingModes, ", "), mode))
+	}
+	for _, name := range booleanHeaders {
+		if value, ok := headers[name]; ok && value != "true" && value != "false" {
+			errs = append(errs, fmt.Errorf("%s: must be true or false, got %q", name, value))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package report
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Fields returned by goal and attribution model if report request has goals
+var GoalFields = []string{"Conversions", "ConversionRate", "CostPerConversion", "GoalsRoi", "Revenue"}
+
+// Goal columns of goals table
+const (
+	ColumnGoalId           = "GoalId"
+	ColumnAttributionModel = "AttributionModel"
+)
+
+// Table define parsed TSV report
+type Table struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// GoalColumn define report column of goal field: <Field>_<GoalId>_<AttributionModel>
+type GoalColumn struct {
+	Field            string
+	GoalId           int64
+	AttributionModel string
+}
+
+// ParseTSV returns table of TSV report body. Headers are report request headers:
+// report title and summary lines are skipped if they are not skipped by API, column header is required
+func ParseTSV(body string, headers map[string]string) (*Table, error) {
+	lines := strings.Split(strings.TrimRight(body, "\n"), "\n")
+	if headers["skipColumnHeader"] == "true" {
+		return nil, errors.New("column header is required, skipColumnHeader must be false")
+	}
+	if headers["skipReportHeader"] != "true" && len(lines) > 0 {
+		lines = lines[1:]
+	}
+	if headers["skipReportSummary"] != "true" && len(lines) > 0 {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 || lines[0] == "" {
+		return nil, errors.New("column header not found")
+	}
+	table := &Table{Columns: strings.Split(lines[0], "\t")}
+	for i, line := range lines[1:] {
+		row := strings.Split(line, "\t")
+		if len(row) != len(table.Columns) {
+			return nil, fmt.Errorf("row %d: %d values, expected %d", i+1, len(row), len(table.Columns))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
+
+// String returns table in TSV format with column header
+func (t *Table) String() string {
+	builder := strings.Builder{}
+	builder.WriteString(strings.Join(t.Columns, "\t") + "\n")
+	for _, row := range t.Rows {
+		builder.WriteString(strings.Join(row, "\t") + "\n")
+	}
+	return builder.String()
+}
+
+// ParseGoalColumn returns goal column of column name, false if column is not goal column
+func ParseGoalColumn(name string) (GoalColumn, bool) {
+	parts := strings.Split(name, "_")
+	if len(parts) != 3 || !slices.Contains(GoalFields, parts[0]) || !slices.Contains(AttributionModels, parts[2]) {
+		return GoalColumn{}, false
+	}
+	goalId, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return GoalColumn{}, false
+	}
+	return GoalColumn{Field: parts[0], GoalId: goalId, AttributionModel: parts[2]}, true
+}
+
+// HasGoals returns true if table has goal columns
+func (t *Table) HasGoals() bool {
+	for _, column := range t.Columns {
+		if _, ok := ParseGoalColumn(column); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Goals returns goals table: goal columns of every row are converted to rows by goal and attribution model.
+// Columns are other table columns, GoalId, AttributionModel and goal fields, e.g. Conversions, Revenue
+func (t *Table) Goals() *Table {
+	// Goal and attribution model pair
+	type goalKey struct {
+		goalId int64
+		model  string
+	}
+	base := []int{}
+	fields := []string{}
+	keys := []goalKey{}
+	index := map[goalKey]map[string]int{}
+	for i, column := range t.Columns {
+		goal, ok := ParseGoalColumn(column)
+		if !ok {
+			base = append(base, i)
+			continue
+		}
+		if !slices.Contains(fields, goal.Field) {
+			fields = append(fields, goal.Field)
+		}
+		key := goalKey{goal.GoalId, goal.AttributionModel}
+		if _, ok := index[key]; !ok {
+			keys = append(keys, key)
+			index[key] = map[string]int{}
+		}
+		index[key][goal.Field] = i
+	}
+	goals := &Table{}
+	for _, i := range base {
+		goals.Columns = append(goals.Columns, t.Columns[i])
+	}
+	goals.Columns = append(goals.Columns, ColumnGoalId, ColumnAttributionModel)
+	goals.Columns = append(goals.Columns, fields...)
+	for _, row := range t.Rows {
+		for _, key := range keys {
+			goalRow := make([]string, 0, len(goals.Columns))
+			for _, i := range base {
+				goalRow = append(goalRow, row[i])
+			}
+			goalRow = append(goalRow, strconv.FormatInt(key.goalId, 10), key.model)
+			for _, field := range fields {
+				value := "--"
+				if i, ok := index[key][field]; ok {
+					value = row[i]
+				}
+				goalRow = append(goalRow, value)
+			}
+			goals.Rows = append(goals.Rows, goalRow)
+		}
+	}
+	return goals
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for working with report schedules state.
+// Schedule state stores last scheduled run time of every report spec to catch up missed runs.
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/db"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Datetime format for schedules table
+const timeFormat = "2006-01-02 15:04:05"
+
+// State define report schedule state
+type State struct {
+	Report    string `db:"report"`
+	LastRunAt string `db:"last_run_at"`
+}
+
+// Store define schedules state store of environment in database
+type Store struct {
+	db          *db.Db
+	environment string
+}
+
+// Returns new schedules state store of environment: production or sandbox
+func NewStore(dbIn *db.Db, environment string) *Store {
+	return &Store{db: dbIn, environment: environment}
+}
+
+// LastRun returns last scheduled run time of report spec.
+// Returns false if report spec has never been run by schedule
+func (s *Store) LastRun(ctx context.Context, report string) (time.Time, bool, error) {
+	query := []string{
+		"SELECT report, DATE_FORMAT(last_run_at, '%Y-%m-%d %H:%i:%s') AS last_run_at",
+		"FROM ydloader_schedules WHERE environment = ? AND report = ?",
+	}
+	state, err := db.QueryOne[State](ctx, s.db, strings.Join(query, " "), []any{s.environment, report})
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return time.Time{}, false, err
+	}
+	lastRunAt, err := time.ParseInLocation(timeFormat, state.LastRunAt, time.UTC)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return time.Time{}, false, err
+	}
+	return lastRunAt, true, nil
+}
+
+// SetLastRun store last scheduled run time of report spec
+func (s *Store) SetLastRun(ctx context.Context, report string, runAt time.Time) error {
+	sql := []string{
+		"INSERT INTO ydloader_schedules (environment, report, last_run_at, updated_at) VALUES (?, ?, ?, ?)",
+		"ON DUPLICATE KEY UPDATE last_run_at = VALUES(last_run_at), updated_at = VALUES(updated_at)",
+	}
+	params := []any{s.environment, report, runAt.UTC().Format(timeFormat), time.Now().UTC().Format(timeFormat)}
+	_, err := s.db.ExecContext(ctx, strings.Join(sql, " "), params)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// EnvProvider returns secrets from environment variables
+type EnvProvider struct{}
+
+// Get returns environment variable value
+func (p EnvProvider) Get(ctx context.Context, ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", errors.New("environment variable is not set")
+	}
+	return value, nil
+}
+
+// FileProvider returns secrets from files, e.g. docker or kubernetes secrets
+type FileProvider struct{}
+
+// Get returns file content without trailing new line
+func (p FileProvider) Get(ctx context.Context, ref string) (string, error) {
+	b, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// VaultProvider returns secrets from Vault-compatible KV v2 HTTP API.
+// Reference format: path#key
+type VaultProvider struct {
+	Address string       // Vault address, e.g. http://127.0.0.1:8200
+	Token   string       // Vault token
+	Mount   string       // KV engine mount, default "secret"
+	Client  *http.Client // HTTP client
+}
+
+// Vault KV v2 read response
+type vaultResponse struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// Returns new Vault provider
+func NewVaultProvider(address string, token string, mount string, timeout time.Duration) *VaultProvider {
+	if mount == "" {
+		mount = "secret"
+	}
+	return &VaultProvider{
+		Address: strings.TrimRight(address, "/"),
+		Token:   token,
+		Mount:   strings.Trim(mount, "/"),
+		Client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Get returns key value of secret by path
+func (p *VaultProvider) Get(ctx context.Context, ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || key == "" {
+		return "", errors.New("vault reference must be path#key")
+	}
+	url := p.Address + "/v1/" + p.Mount + "/data/" + strings.Trim(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", p.Token)
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	data := vaultResponse{}
+	err = json.Unmarshal(body, &data)
+	if err != nil && resp.StatusCode == http.StatusOK {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned %s %s", resp.Status, strings.Join(data.Errors, "; "))
+	}
+	value, ok := data.Data.Data[key]
+	if !ok {
+		return "", errors.New("vault secret has no key " + key)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", errors.New("vault secret key " + key + " is not a string")
+	}
+	return s, nil
+}
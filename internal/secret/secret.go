@@ -0,0 +1,112 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for working with secrets.
+// Secret value can be a plain value or a reference to secret provider:
+// env:NAME, file:path, vault:path#key or encrypted value enc:v1:...
+package secret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Provider define interface for secret provider
+type Provider interface {
+	// Get returns secret value by provider specific reference
+	Get(ctx context.Context, ref string) (string, error)
+}
+
+// Resolver resolves secret references using registered providers
+type Resolver struct {
+	providers map[string]Provider
+	cipher    *Cipher
+}
+
+// Returns new resolver with env and file providers
+func NewResolver() *Resolver {
+	return &Resolver{
+		providers: map[string]Provider{
+			"env":  EnvProvider{},
+			"file": FileProvider{},
+		},
+	}
+}
+
+// Register provider for reference scheme
+func (r *Resolver) Register(scheme string, provider Provider) {
+	r.providers[scheme] = provider
+}
+
+// SetCipher set cipher for encrypted values
+func (r *Resolver) SetCipher(cipher *Cipher) {
+	r.cipher = cipher
+}
+
+// Resolve returns secret value for plain value, provider reference or encrypted value
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	if IsEncrypted(value) {
+		if r.cipher == nil {
+			err := errors.New("encrypted secret found, but encryption key is not configured")
+			fmt.Println(err, trace.GetTrace())
+			return "", err
+		}
+		return r.cipher.Decrypt(value)
+	}
+	scheme, ref, ok := strings.Cut(value, ":")
+	if !ok {
+		return value, nil
+	}
+	provider, ok := r.providers[scheme]
+	if !ok {
+		return value, nil
+	}
+	resolved, err := provider.Get(ctx, ref)
+	if err != nil {
+		err = fmt.Errorf("secret %s: %w", scheme+":"+ref, err)
+		fmt.Println(err, trace.GetTrace())
+		return "", err
+	}
+	// Provider may store encrypted value
+	if IsEncrypted(resolved) {
+		return r.Resolve(ctx, resolved)
+	}
+	return resolved, nil
+}
+
+// ResolveStored returns secret value for plain or encrypted value stored in DB.
+// Provider references are rejected, so anyone writing DB rows can't read local environment and files
+func (r *Resolver) ResolveStored(ctx context.Context, value string) (string, error) {
+	if IsEncrypted(value) {
+		return r.Resolve(ctx, value)
+	}
+	scheme, _, ok := strings.Cut(value, ":")
+	if _, isProvider := r.providers[scheme]; ok && isProvider {
+		err := fmt.Errorf("secret reference %s: is not allowed in stored value", scheme)
+		fmt.Println(err, trace.GetTrace())
+		return "", err
+	}
+	return value, nil
+}
+
+// Encrypt returns encrypted value if cipher is set, plain value otherwise
+func (r *Resolver) Encrypt(value string) (string, error) {
+	if r.cipher == nil || value == "" {
+		return value, nil
+	}
+	return r.cipher.Encrypt(value)
+}
+
+// Mask returns secret value safe for logs: only last 4 chars are visible for long values
+func Mask(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) <= 12 {
+		return "****"
+	}
+	return "****" + value[len(value)-4:]
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package secret
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Returns cipher with new random key
+func newTestCipher(t *testing.T) *Cipher {
+	t.Helper()
+	text, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseKey(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCipher(t *testing.T) {
+	c := newTestCipher(t)
+	encrypted, err := c.Encrypt("y0_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(encrypted) || strings.Contains(encrypted, "y0_token") {
+		t.Fatalf("value is not encrypted: %s", encrypted)
+	}
+	plain, err := c.Decrypt(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain != "y0_token" {
+		t.Errorf("Decrypt() = %q, want y0_token", plain)
+	}
+	other := newTestCipher(t)
+	if _, err := other.Decrypt(encrypted); err == nil {
+		t.Error("value is decrypted with other key")
+	}
+	for _, value := range []string{"y0_token", EncryptedPrefix + "not base64!", EncryptedPrefix + "AAAA"} {
+		if _, err := c.Decrypt(value); err == nil {
+			t.Errorf("Decrypt(%q) must fail", value)
+		}
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	hexKey := strings.Repeat("ab", 32)
+	if _, err := ParseKey(" " + hexKey + "\n"); err != nil {
+		t.Errorf("hex key: %s", err)
+	}
+	if _, err := ParseKey("c2hvcnQ="); err == nil {
+		t.Error("short key must fail")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	c := newTestCipher(t)
+	encrypted, err := c.Encrypt("from-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "token")
+	err = os.WriteFile(file, []byte(encrypted+"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("YDLOADER_TEST_SECRET", "from-env")
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" || r.URL.Path != "/v1/kv/data/ydloader" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"token":"from-vault"}}}`))
+	}))
+	defer vault.Close()
+	r := NewResolver()
+	r.SetCipher(c)
+	r.Register("vault", NewVaultProvider(vault.URL, "vault-token", "kv", time.Second))
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"plain", "plain"},
+		{"unknown:scheme", "unknown:scheme"},
+		{"env:YDLOADER_TEST_SECRET", "from-env"},
+		{"file:" + file, "from-file"},
+		{"vault:ydloader#token", "from-vault"},
+		{encrypted, "from-file"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		got, err := r.Resolve(ctx, tt.value)
+		if err != nil {
+			t.Errorf("Resolve(%q): %s", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+	for _, value := range []string{"env:YDLOADER_TEST_MISSING", "vault:other#token", "vault:ydloader#missing", "vault:ydloader"} {
+		if _, err := r.Resolve(ctx, value); err == nil {
+			t.Errorf("Resolve(%q) must fail", value)
+		}
+	}
+	if _, err := NewResolver().Resolve(ctx, encrypted); err == nil {
+		t.Error("encrypted value must fail without key")
+	}
+}
+
+func TestResolveStored(t *testing.T) {
+	c := newTestCipher(t)
+	encrypted, err := c.Encrypt("y0_stored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("YDLOADER_TEST_SECRET", "from-env")
+	r := NewResolver()
+	r.SetCipher(c)
+	r.Register("vault", NewVaultProvider("http://127.0.0.1:1", "", "kv", time.Second))
+	ctx := context.Background()
+	for _, tt := range []struct {
+		value string
+		want  string
+	}{
+		{"y0_plain", "y0_plain"},
+		{"unknown:scheme", "unknown:scheme"},
+		{encrypted, "y0_stored"},
+	} {
+		got, err := r.ResolveStored(ctx, tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveStored(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+	for _, value := range []string{"env:YDLOADER_TEST_SECRET", "file:/etc/passwd", "vault:ydloader#token"} {
+		if got, err := r.ResolveStored(ctx, value); err == nil {
+			t.Errorf("ResolveStored(%q) = %q, must fail", value, got)
+		}
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"short", "****"},
+		{"123456789012", "****"},
+		{"y0_AgAAAAAtoken1234", "****1234"},
+	}
+	for _, tt := range tests {
+		if got := Mask(tt.value); got != tt.want {
+			t.Errorf("Mask(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
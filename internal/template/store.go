@@ -0,0 +1,92 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Store holds json templates of directory, safe for concurrent use.
+// Broken template is rejected on reload and last valid version is kept
+type Store struct {
+	dir       string
+	templates map[string]TemplateManager
+	mu        sync.RWMutex
+}
+
+// Returns new store for templates directory
+func NewStore(dir string) *Store {
+	return &Store{dir: dir, templates: map[string]TemplateManager{}}
+}
+
+// Dir returns templates directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Get returns template manager by template file name, e.g. body.json
+func (s *Store) Get(name string) (TemplateManager, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tm, ok := s.templates[name]
+	if !ok {
+		return TemplateManager{}, errors.New("template not found: " + name)
+	}
+	return tm, nil
+}
+
+// Load read and validate all *.json templates of directory.
+// Returns joined errors of rejected templates, valid templates are applied
+func (s *Store) Load() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	loaded := map[string]TemplateManager{}
+	errs := []error{}
+	for _, path := range paths {
+		tm, err := loadTemplate(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		loaded[filepath.Base(path)] = tm
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, tm := range loaded {
+		s.templates[name] = tm
+	}
+	// Templates removed from directory are not available for new jobs
+	for name := range s.templates {
+		if _, err := os.Stat(filepath.Join(s.dir, name)); os.IsNotExist(err) {
+			delete(s.templates, name)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Read and validate template file
+func loadTemplate(path string) (TemplateManager, error) {
+	tm := TemplateManager{}
+	err := tm.SetTemplate(path)
+	if err != nil {
+		return tm, err
+	}
+	if strings.TrimSpace(tm.template) == "" {
+		return tm, fmt.Errorf("template %s is empty", path)
+	}
+	if !json.Valid([]byte(tm.template)) {
+		return tm, fmt.Errorf("template %s is not valid json", path)
+	}
+	return tm, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for working with account timezones of logins.
+// Report dates of login are computed in account timezone of login.
+package timezones
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/db"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Date format of report dates
+const dateFormat = "2006-01-02"
+
+// Datetime format for timezones table
+const timeFormat = "2006-01-02 15:04:05"
+
+// Date returns date of day offset days relative to today in location of now, e.g. -1 is yesterday.
+// Dates are counted by calendar days, so DST transitions do not shift dates
+func Date(now time.Time, offset int) string {
+	year, month, day := now.Date()
+	return time.Date(year, month, day+offset, 0, 0, 0, 0, time.UTC).Format(dateFormat)
+}
+
+// Timezone define account timezone of login
+type Timezone struct {
+	Login    string `db:"login"`
+	Timezone string `db:"timezone"`
+}
+
+// Store define login timezones store in database
+type Store struct {
+	db *db.Db
+}
+
+// Returns new login timezones store
+func NewStore(dbIn *db.Db) *Store {
+	return &Store{db: dbIn}
+}
+
+// Get returns timezone of login, empty if login timezone is not stored
+func (s *Store) Get(ctx context.Context, login string) (string, error) {
+	query := "SELECT login, timezone FROM ydloader_timezones WHERE login = ?"
+	item, err := db.QueryOne[Timezone](ctx, s.db, query, []any{login})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return "", err
+	}
+	return item.Timezone, nil
+}
+
+// Set store timezone of login
+func (s *Store) Set(ctx context.Context, login string, timezone string) error {
+	sql := []string{
+		"INSERT INTO ydloader_timezones (login, timezone, updated_at) VALUES (?, ?, ?)",
+		"ON DUPLICATE KEY UPDATE timezone = VALUES(timezone), updated_at = VALUES(updated_at)",
+	}
+	params := []any{login, timezone, time.Now().UTC().Format(timeFormat)}
+	_, err := s.db.ExecContext(ctx, strings.Join(sql, " "), params)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+package timezones
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDate(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		now    time.Time
+		offset int
+		want   string
+	}{
+		{"spring forward day, today", time.Date(2024, 3, 10, 3, 30, 0, 0, newYork), 0, "2024-03-10"},
+		{"spring forward day, yesterday", time.Date(2024, 3, 10, 3, 30, 0, 0, newYork), -1, "2024-03-09"},
+		{"after spring forward, just after midnight", time.Date(2024, 3, 11, 0, 30, 0, 0, newYork), -1, "2024-03-10"},
+		{"week across spring forward", time.Date(2024, 3, 12, 0, 15, 0, 0, newYork), -7, "2024-03-05"},
+		{"fall back day, before midnight", time.Date(2024, 11, 3, 23, 30, 0, 0, newYork), -1, "2024-11-02"},
+		{"after fall back, just after midnight", time.Date(2024, 11, 4, 0, 5, 0, 0, newYork), -1, "2024-11-03"},
+		{"europe spring forward, just after midnight", time.Date(2024, 4, 1, 0, 30, 0, 0, berlin), -1, "2024-03-31"},
+		{"europe fall back day, before midnight", time.Date(2024, 10, 27, 23, 30, 0, 0, berlin), -1, "2024-10-26"},
+		{"month boundary", time.Date(2024, 3, 1, 0, 0, 0, 0, berlin), -1, "2024-02-29"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Date(tt.now, tt.offset)
+			if got != tt.want {
+				t.Errorf("Date(%s, %d) = %s, want %s", tt.now, tt.offset, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Aleksei Grigorev
+// https://aleksvgrig.com, https://github.com/AlekseiGrigorev, aleksvgrig@gmail.com.
+// Package define interfaces, structures and functions for working with integration tokens state.
+// Token state stores invalid tokens and tokens refreshed with OAuth refresh token flow.
+package tokens
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/db"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Token statuses
+const (
+	StatusValid   = "valid"
+	StatusInvalid = "invalid"
+)
+
+// Datetime format for tokens table
+const timeFormat = "2006-01-02 15:04:05"
+
+// State define integration token state
+type State struct {
+	IntegrationId int    `db:"integration_id"`
+	Status        string `db:"status"`
+	Reason        string `db:"reason"`
+	InvalidSince  string `db:"invalid_since"`
+	AccessToken   string `db:"access_token"`
+	RefreshToken  string `db:"refresh_token"`
+	ExpiresAt     string `db:"expires_at"`
+}
+
+// Expired returns true if refreshed access token expiration time passed
+func (s *State) Expired(now time.Time) bool {
+	if s.ExpiresAt == "" {
+		return false
+	}
+	expiresAt, err := time.Parse(timeFormat, s.ExpiresAt)
+	if err != nil {
+		return false
+	}
+	return !now.UTC().Before(expiresAt)
+}
+
+// Source define interface for token source
+type Source interface {
+	// Get returns integration token state, nil if state is not stored
+	Get(ctx context.Context, integrationId int) (*State, error)
+	// Invalidate mark integration token as invalid
+	Invalidate(ctx context.Context, integrationId int, reason string) error
+	// Save store refreshed token and mark it valid
+	Save(ctx context.Context, integrationId int, accessToken string, refreshToken string, expiresAt time.Time) error
+	// SetRefreshToken store refresh token for integration
+	SetRefreshToken(ctx context.Context, integrationId int, refreshToken string) error
+	// List returns all stored token states
+	List(ctx context.Context) ([]*State, error)
+}
+
+// Store define token source in database
+type Store struct {
+	db *db.Db
+}
+
+// Returns new token state store
+func NewStore(dbIn *db.Db) *Store {
+	return &Store{db: dbIn}
+}
+
+// Columns of token state select
+var selectSql = []string{
+	"SELECT integration_id, status, IFNULL(reason, '') AS reason,",
+	"IFNULL(DATE_FORMAT(invalid_since, '%Y-%m-%d %H:%i:%s'), '') AS invalid_since,",
+	"IFNULL(access_token, '') AS access_token, IFNULL(refresh_token, '') AS refresh_token,",
+	"IFNULL(DATE_FORMAT(expires_at, '%Y-%m-%d %H:%i:%s'), '') AS expires_at",
+	"FROM ydloader_tokens",
+}
+
+// Get returns integration token state, nil if state is not stored
+func (s *Store) Get(ctx context.Context, integrationId int) (*State, error) {
+	query := strings.Join(append(selectSql, "WHERE integration_id = ?"), " ")
+	state, err := db.QueryOne[State](ctx, s.db, query, []any{integrationId})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	return state, nil
+}
+
+// List returns all stored token states
+func (s *Store) List(ctx context.Context) ([]*State, error) {
+	query := strings.Join(append(selectSql, "ORDER BY integration_id"), " ")
+	states, err := db.QueryAll[State](ctx, s.db, query, nil)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return nil, err
+	}
+	return states, nil
+}
+
+// Invalidate mark integration token as invalid
+func (s *Store) Invalidate(ctx context.Context, integrationId int, reason string) error {
+	now := time.Now().UTC().Format(timeFormat)
+	sql := []string{
+		"INSERT INTO ydloader_tokens (integration_id, status, reason, invalid_since, updated_at)",
+		"VALUES (?, ?, ?, ?, ?)",
+		"ON DUPLICATE KEY UPDATE status = VALUES(status), reason = VALUES(reason),",
+		"invalid_since = IFNULL(invalid_since, VALUES(invalid_since)), updated_at = VALUES(updated_at)",
+	}
+	_, err := s.db.ExecContext(ctx, strings.Join(sql, " "), []any{integrationId, StatusInvalid, reason, now, now})
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	return nil
+}
+
+// Save store refreshed token and mark it valid.
+// Refresh token is not changed if empty
+func (s *Store) Save(ctx context.Context, integrationId int, accessToken string, refreshToken string, expiresAt time.Time) error {
+	now := time.Now().UTC().Format(timeFormat)
+	var expires any
+	if !expiresAt.IsZero() {
+		expires = expiresAt.UTC().Format(timeFormat)
+	}
+	var refresh any
+	if refreshToken != "" {
+		refresh = refreshToken
+	}
+	sql := []string{
+		"INSERT INTO ydloader_tokens (integration_id, status, access_token, refresh_token, expires_at, updated_at)",
+		"VALUES (?, ?, ?, ?, ?, ?)",
+		"ON DUPLICATE KEY UPDATE status = VALUES(status), reason = NULL, invalid_since = NULL,",
+		"access_token = VALUES(access_token), refresh_token = IFNULL(VALUES(refresh_token), refresh_token),",
+		"expires_at = VALUES(expires_at), updated_at = VALUES(updated_at)",
+	}
+	params := []any{integrationId, StatusValid, accessToken, refresh, expires, now}
+	_, err := s.db.ExecContext(ctx, strings.Join(sql, " "), params)
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	return nil
+}
+
+// SetRefreshToken store refresh token for integration
+func (s *Store) SetRefreshToken(ctx context.Context, integrationId int, refreshToken string) error {
+	now := time.Now().UTC().Format(timeFormat)
+	sql := []string{
+		"INSERT INTO ydloader_tokens (integration_id, refresh_token, updated_at)",
+		"VALUES (?, ?, ?)",
+		"ON DUPLICATE KEY UPDATE refresh_token = VALUES(refresh_token), updated_at = VALUES(updated_at)",
+	}
+	_, err := s.db.ExecContext(ctx, strings.Join(sql, " "), []any{integrationId, refreshToken, now})
+	if err != nil {
+		fmt.Println(err, trace.GetTrace())
+		return err
+	}
+	return nil
+}
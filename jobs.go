@@ -0,0 +1,153 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/logger"
+)
+
+// Job statuses
+const (
+	JobPending  = "pending"  // Not started yet
+	JobWaiting  = "waiting"  // Waiting for next try: report is building or request failed
+	JobRunning  = "running"  // Request in progress
+	JobDone     = "done"     // Report loaded
+	JobFailed   = "failed"   // Job failed
+	JobCanceled = "canceled" // Job canceled
+)
+
+// Job ids sequence of this process
+var jobSeq atomic.Int64
+
+// Jobs of current and last runs of every report spec
+var AppJobs = &jobRegistry{runs: map[string][]*BaseStruct{}}
+
+// Job registry, keeps jobs of the last run of every report spec
+type jobRegistry struct {
+	mu   sync.RWMutex
+	runs map[string][]*BaseStruct
+}
+
+// Set jobs of report spec run, assigns job ids
+func (r *jobRegistry) Set(report string, structs []*BaseStruct) {
+	for _, baseStruct := range structs {
+		baseStruct.Id = jobSeq.Add(1)
+		baseStruct.Report = report
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[report] = structs
+}
+
+// List returns all jobs ordered by id
+func (r *jobRegistry) List() []*BaseStruct {
+	r.mu.RLock()
+	structs := []*BaseStruct{}
+	for _, run := range r.runs {
+		structs = append(structs, run...)
+	}
+	r.mu.RUnlock()
+	slices.SortFunc(structs, func(a, b *BaseStruct) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return structs
+}
+
+// Get returns job by id, nil if job is not found
+func (r *jobRegistry) Get(id int64) *BaseStruct {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, run := range r.runs {
+		for _, baseStruct := range run {
+			if baseStruct.Id == id {
+				return baseStruct
+			}
+		}
+	}
+	return nil
+}
+
+// JobInfo define job state snapshot
+type JobInfo struct {
+	Id            int64     `json:"id"`
+	RunId         int64     `json:"run_id"`
+	Report        string    `json:"report"`
+	IntegrationId int       `json:"integration_id"`
+	Login         string    `json:"login"`
+	ReportName    string    `json:"report_name"`
+	DateFrom      string    `json:"date_from"`
+	DateTo        string    `json:"date_to"`
+	Status        string    `json:"status"`
+	Try           int       `json:"try"`
+	NextTry       time.Time `json:"next_try"`
+	Error         string    `json:"error,omitempty"`
+	RequestId     string    `json:"request_id,omitempty"`
+	FilePath      string    `json:"file_path,omitempty"`
+	QualityError  string    `json:"quality_error,omitempty"`
+}
+
+// Returns job status, job must be locked by caller
+func (b *BaseStruct) status() string {
+	switch {
+	case b.Processed && b.Failed && b.Canceled:
+		return JobCanceled
+	case b.Processed && b.Failed:
+		return JobFailed
+	case b.Processed:
+		return JobDone
+	case b.Started:
+		return JobRunning
+	case b.Try == 0:
+		return JobPending
+	}
+	return JobWaiting
+}
+
+// Info returns job state snapshot
+func (b *BaseStruct) Info() JobInfo {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return JobInfo{
+		Id:            b.Id,
+		RunId:         b.RunId,
+		Report:        b.Report,
+		IntegrationId: b.IntegrationId,
+		Login:         b.Login,
+		ReportName:    b.ReportName,
+		DateFrom:      b.DateFrom,
+		DateTo:        b.DateTo,
+		Status:        b.status(),
+		Try:           b.Try,
+		NextTry:       b.NextTry,
+		Error:         b.Error,
+		RequestId:     b.RequestId,
+		FilePath:      b.FilePath,
+		QualityError:  b.QualityError,
+	}
+}
+
+// Returns logger with job attributes: login, report, attempt and request id of the last response.
+// Must be called by goroutine which owns the job: jobs runner or request goroutine
+func jobLog(b *BaseStruct) *logger.Log {
+	args := []any{"login", b.Login, "report", b.Report, "attempt", b.Try}
+	if b.RequestId != "" {
+		args = append(args, "request_id", b.RequestId)
+	}
+	return Log.With(args...)
+}
+
+// Cancel job. Request in progress is finished, job is not retried after it.
+// Returns false if job is already processed
+func (b *BaseStruct) Cancel() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.Processed {
+		return false
+	}
+	b.Canceled = true
+	return true
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/config"
+	"github.com/AlekseiGrigorev/ydloader/internal/logger"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Open configured log outputs and set them as log writer.
+// Returns function closing outputs
+func openLogOutputs(conf config.Log) (func(), error) {
+	writers := logger.Tee{}
+	closers := []io.Closer{}
+	closeAll := func() {
+		for _, closer := range closers {
+			closer.Close()
+		}
+	}
+	for _, output := range conf.Outputs {
+		switch output {
+		case logger.OutputStdout:
+			writers = append(writers, os.Stdout)
+		case logger.OutputStderr:
+			writers = append(writers, os.Stderr)
+		case logger.OutputFile:
+			file, err := logger.NewRotatingFile(conf.File.Path, logger.RotateOptions{
+				MaxSize:    int64(conf.File.MaxSize) << 20,
+				Daily:      conf.File.Daily,
+				MaxAge:     conf.File.MaxAge,
+				MaxBackups: conf.File.MaxBackups,
+				Compress:   conf.File.Compress,
+			})
+			if err != nil {
+				closeAll()
+				Log.Error(err, trace.GetTrace())
+				return nil, err
+			}
+			writers = append(writers, file)
+			closers = append(closers, file)
+		case logger.OutputSyslog:
+			writer, err := logger.NewSyslog(conf.Syslog.Network, conf.Syslog.Address, conf.Syslog.Tag)
+			if err != nil {
+				closeAll()
+				Log.Error(err, trace.GetTrace())
+				return nil, err
+			}
+			writers = append(writers, writer)
+			closers = append(closers, writer)
+		}
+	}
+	Log.SetOutput(writers)
+	return func() {
+		// Closed outputs are not used by records written after close
+		Log.SetOutput(io.Discard)
+		closeAll()
+	}, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/metrics"
+)
+
+// Application metrics
+var AppMetrics = metrics.NewRegistry()
+
+// Buckets of report API request latency, seconds
+var requestBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 180}
+
+// Buckets of offline report queue wait time, seconds
+var queueBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200, 1800, 3600}
+
+var (
+	metricJobs = AppMetrics.Gauge("ydloader_jobs",
+		"Jobs of current and last runs by report spec and status", "report", "status")
+	metricRequests = AppMetrics.Counter("ydloader_http_requests_total",
+		"Report API requests by status code, code is error if request failed", "report", "code")
+	metricRetries = AppMetrics.Counter("ydloader_retries_total",
+		"Report API request retries, including polls of reports in queue", "report")
+	metricBytes = AppMetrics.Counter("ydloader_report_bytes_total",
+		"Downloaded report bytes", "report")
+	metricRows = AppMetrics.Counter("ydloader_report_rows_total",
+		"Downloaded report rows", "report")
+	metricUnitsSpent = AppMetrics.Counter("ydloader_units_spent_total",
+		"API units spent by integration token", "integration_id")
+	metricUnitsLeft = AppMetrics.Gauge("ydloader_units_left",
+		"API units left by integration token", "integration_id")
+	metricUnitsLimit = AppMetrics.Gauge("ydloader_units_limit",
+		"API units daily limit by integration token", "integration_id")
+	metricLatency = AppMetrics.Histogram("ydloader_request_duration_seconds",
+		"Report API request latency", requestBuckets, "report")
+	metricQueueWait = AppMetrics.Histogram("ydloader_report_queue_wait_seconds",
+		"Time from the first request of job until report is ready", queueBuckets, "report")
+)
+
+func init() {
+	AppMetrics.OnCollect(collectJobs)
+}
+
+// Serializes jobs gauge updates of concurrent scrapes
+var collectJobsMu sync.Mutex
+
+// Update jobs gauge from jobs registry. Jobs are counted from locked snapshots
+// before the gauge is reset, so a scrape never sees a partially filled gauge
+func collectJobs() {
+	counts := map[[2]string]int{}
+	for _, baseStruct := range AppJobs.List() {
+		info := baseStruct.Info()
+		counts[[2]string{info.Report, info.Status}]++
+	}
+	collectJobsMu.Lock()
+	defer collectJobsMu.Unlock()
+	metricJobs.Reset()
+	for labels, count := range counts {
+		metricJobs.With(labels[0], labels[1]).Set(float64(count))
+	}
+}
+
+// Observe report API response of job. Response is nil if request failed
+func observeResponse(baseStruct *BaseStruct, resp *RespStruct, duration time.Duration) {
+	metricLatency.With(baseStruct.Report).Observe(duration.Seconds())
+	if resp == nil {
+		metricRequests.With(baseStruct.Report, "error").Inc()
+		return
+	}
+	metricRequests.With(baseStruct.Report, strconv.Itoa(resp.StatusCode)).Inc()
+	integrationId := strconv.Itoa(baseStruct.IntegrationId)
+	units := strings.Split(resp.Header.Get("Units"), "/")
+	if len(units) == 3 {
+		spent, _ := strconv.ParseFloat(units[0], 64)
+		left, _ := strconv.ParseFloat(units[1], 64)
+		limit, _ := strconv.ParseFloat(units[2], 64)
+		metricUnitsSpent.With(integrationId).Add(spent)
+		metricUnitsLeft.With(integrationId).Set(left)
+		metricUnitsLimit.With(integrationId).Set(limit)
+	}
+	if resp.StatusCode != 200 {
+		return
+	}
+	baseStruct.mu.Lock()
+	headers, firstTry := baseStruct.Headers, baseStruct.FirstTry
+	baseStruct.mu.Unlock()
+	metricBytes.With(baseStruct.Report).Add(float64(len(resp.Body)))
+	metricRows.With(baseStruct.Report).Add(float64(reportRows(headers, resp.Body)))
+	if !firstTry.IsZero() {
+		metricQueueWait.With(baseStruct.Report).Observe(time.Since(firstTry).Seconds())
+	}
+}
+
+// Returns count of data rows in TSV report, report header, column header and summary lines are skipped
+func reportRows(headersJson string, body string) int {
+	headers := map[string]string{}
+	json.Unmarshal([]byte(headersJson), &headers)
+	rows := 0
+	for _, line := range strings.Split(body, "\n") {
+		if strings.TrimSpace(line) != "" {
+			rows++
+		}
+	}
+	for _, name := range []string{"skipReportHeader", "skipColumnHeader", "skipReportSummary"} {
+		if headers[name] != "true" && rows > 0 {
+			rows--
+		}
+	}
+	return rows
+}
+
+// Start metrics server if enabled. Server is stopped when context is done
+func startMetrics(ctx context.Context) {
+	conf := AppConfig.Get().Metrics
+	if !conf.Enabled {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET "+conf.Path, AppMetrics.Handler())
+	server := &http.Server{
+		Addr:              conf.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			Log.Error("Metrics server failed", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), AdminShutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	Log.Info("Metrics server started", conf.Listen+conf.Path)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/migrate"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Run migrate command: migrate up [n] | down [n] | status
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("usage: ydloader migrate up [n] | down [n] | status")
+	}
+	steps := 0
+	if flags.NArg() > 1 {
+		steps, err = strconv.Atoi(flags.Arg(1))
+		if err != nil {
+			return err
+		}
+	}
+	migrator, err := migrate.New(&AppDb)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	ctx := context.Background()
+	switch flags.Arg(0) {
+	case "up":
+		done, err := migrator.Up(ctx, steps)
+		for _, m := range done {
+			Log.Info("Migration applied", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			Log.Info("No pending migrations")
+		}
+		return nil
+	case "down":
+		done, err := migrator.Down(ctx, steps)
+		for _, m := range done {
+			Log.Info("Migration rolled back", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			Log.Info("No applied migrations")
+		}
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt
+			}
+			fmt.Printf("%6d  %-40s %s\n", s.Version, s.Name, state)
+		}
+		return nil
+	}
+	return errors.New("unknown migrate command: " + flags.Arg(0))
+}
@@ -0,0 +1,22 @@
+package integrations
+
+import (
+	"strings"
+)
+
+type Integration struct {
+	Id    int    `db:"id"`
+	Token string `db:"token"`
+}
+
+func (model *Integration) GetDefaultSql() string {
+	var sql = []string{
+		"SELECT",
+		"i.id AS id, i.token AS token",
+		"FROM smartis_stat.integrations i",
+		"WHERE i.isActive = 1 AND i.isDeleted = 0",
+		"ORDER BY i.id",
+		";",
+	}
+	return strings.Join(sql, " ")
+}
@@ -2,12 +2,10 @@ package integrations
 
 import (
 	"strings"
-
-	"github.com/AlekseiGrigorev/ydloader/internal/db"
 )
 
 type Token struct {
-	Token string
+	Token string `db:"token"`
 }
 
 func (model *Token) GetDefaultSql() string {
@@ -20,13 +18,3 @@ func (model *Token) GetDefaultSql() string {
 	}
 	return strings.Join(sql, " ")
 }
-
-func (model *Token) GetNewModel() db.RowModel {
-	return new(Token)
-}
-
-func (model *Token) GetColumnPointers() []interface{} {
-	columnPointers := make([]interface{}, 1)
-	columnPointers[0] = &model.Token
-	return columnPointers
-}
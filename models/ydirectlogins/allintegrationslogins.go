@@ -1,16 +1,17 @@
 package ydirectlogins
 
 import (
+	"fmt"
 	"strings"
 
-	"github.com/AlekseiGrigorev/ydloader/internal/db"
+	"github.com/AlekseiGrigorev/ydloader/internal/secret"
 )
 
 type AllIntegrationsLogin struct {
-	Id            int
-	Login         string
-	IntegrationId int
-	Token         string
+	Id            int    `db:"id"`
+	Login         string `db:"login"`
+	IntegrationId int    `db:"integration_id"`
+	Token         string `db:"token"`
 }
 
 func (model *AllIntegrationsLogin) GetDefaultSql() string {
@@ -26,23 +27,7 @@ func (model *AllIntegrationsLogin) GetDefaultSql() string {
 	return strings.Join(sql, " ")
 }
 
-func (model *AllIntegrationsLogin) GetNewModel() db.RowModel {
-	return new(AllIntegrationsLogin)
-}
-
-func (model *AllIntegrationsLogin) GetColumnPointers() []interface{} {
-	columnPointers := make([]interface{}, 4)
-	columnPointers[0] = &model.Id
-	columnPointers[1] = &model.Login
-	columnPointers[2] = &model.IntegrationId
-	columnPointers[3] = &model.Token
-	return columnPointers
-}
-
-func (model *AllIntegrationsLogin) ToType(items []db.RowModel) []*AllIntegrationsLogin {
-	tItems := []*AllIntegrationsLogin{}
-	for _, item := range items {
-		tItems = append(tItems, item.(*AllIntegrationsLogin))
-	}
-	return tItems
+// String returns login data with masked token, safe for logs
+func (model AllIntegrationsLogin) String() string {
+	return fmt.Sprintf("{%d %s %d %s}", model.Id, model.Login, model.IntegrationId, secret.Mask(model.Token))
 }
@@ -2,13 +2,11 @@ package ydirectlogins
 
 import (
 	"strings"
-
-	"github.com/AlekseiGrigorev/ydloader/internal/db"
 )
 
 type IntegrationLogin struct {
-	Id    int
-	Login string
+	Id    int    `db:"id"`
+	Login string `db:"login"`
 }
 
 func (model *IntegrationLogin) GetDefaultSql() string {
@@ -22,22 +20,3 @@ func (model *IntegrationLogin) GetDefaultSql() string {
 	}
 	return strings.Join(sql, " ")
 }
-
-func (model *IntegrationLogin) GetNewModel() db.RowModel {
-	return new(IntegrationLogin)
-}
-
-func (model *IntegrationLogin) GetColumnPointers() []interface{} {
-	columnPointers := make([]interface{}, 2)
-	columnPointers[0] = &model.Id
-	columnPointers[1] = &model.Login
-	return columnPointers
-}
-
-func (model *IntegrationLogin) ToType(items []db.RowModel) []*IntegrationLogin {
-	tItems := []*IntegrationLogin{}
-	for _, item := range items {
-		tItems = append(tItems, item.(*IntegrationLogin))
-	}
-	return tItems
-}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/config"
+	"github.com/AlekseiGrigorev/ydloader/internal/direct"
+	"github.com/AlekseiGrigorev/ydloader/internal/money"
+	"github.com/AlekseiGrigorev/ydloader/internal/report"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Accounts of logins: currency and VAT rate, cached for the run
+var accounts = map[string]direct.ClientInfo{}
+var accountsMu sync.Mutex
+
+// Reset cached accounts before new run
+func resetAccounts() {
+	accountsMu.Lock()
+	defer accountsMu.Unlock()
+	accounts = map[string]direct.ClientInfo{}
+}
+
+// Returns account of job login from clients service
+func jobAccount(baseStruct *BaseStruct) (direct.ClientInfo, error) {
+	accountsMu.Lock()
+	defer accountsMu.Unlock()
+	if account, ok := accounts[baseStruct.Login]; ok {
+		return account, nil
+	}
+	timeout := time.Duration(AppConfig.Get().Http.Timeout) * time.Second
+	baseStruct.mu.Lock()
+	token := baseStruct.Token
+	baseStruct.mu.Unlock()
+	client := direct.NewClient(AppConfig.Get().ApiUrl(), token, timeout).WithLogin(baseStruct.Login)
+	clients, err := client.Clients(context.Background())
+	if err != nil {
+		return direct.ClientInfo{}, err
+	}
+	if len(clients) == 0 {
+		return direct.ClientInfo{}, errors.New("client not found: " + baseStruct.Login)
+	}
+	accounts[baseStruct.Login] = clients[0]
+	return clients[0], nil
+}
+
+// Write money table of report next to response file: <response file>-money.tsv.
+// Include VAT is IncludeVAT of report request
+func writeMoney(baseStruct *BaseStruct, filePath string, table *report.Table, includeVat bool) error {
+	conf := AppConfig.Get().Money
+	if !conf.Table {
+		return nil
+	}
+	account, err := jobAccount(baseStruct)
+	if err != nil {
+		return err
+	}
+	opts, err := moneyOptions(conf, account, includeVat)
+	if err != nil {
+		return err
+	}
+	result, err := money.Convert(table, opts)
+	if err != nil {
+		return err
+	}
+	moneyPath := strings.TrimSuffix(filePath, ".txt") + "-money.tsv"
+	err = os.WriteFile(moneyPath, []byte(result.String()), 0777)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	jobLog(baseStruct).Info("Money written", moneyPath)
+	return nil
+}
+
+// Returns money conversion options of account: VAT rate of account and rate of account currency to reporting currency
+func moneyOptions(conf config.Money, account direct.ClientInfo, includeVat bool) (money.Options, error) {
+	vatRate, err := money.Parse(strconv.FormatFloat(account.VatRate, 'f', -1, 64))
+	if err != nil {
+		return money.Options{}, err
+	}
+	opts := money.Options{
+		Micros:          conf.Micros,
+		IncludeVat:      includeVat,
+		VatRate:         vatRate,
+		AccountCurrency: account.Currency,
+		Currency:        account.Currency,
+		Rate:            money.FromMicros(1000000),
+	}
+	if conf.Currency != "" && conf.Currency != account.Currency {
+		// Config keys are lowercase
+		rate, ok := conf.Rates[strings.ToLower(account.Currency)]
+		if !ok {
+			return opts, errors.New("no rate of " + account.Currency + " to " + conf.Currency)
+		}
+		opts.Currency = conf.Currency
+		opts.Rate, err = money.Parse(rate)
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/config"
+	"github.com/AlekseiGrigorev/ydloader/internal/direct"
+	"github.com/AlekseiGrigorev/ydloader/internal/money"
+	"github.com/AlekseiGrigorev/ydloader/internal/report"
+)
+
+func TestMoneyOptionsUppercaseRates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "money:\n  table: true\n  currency: RUB\n  rates:\n    USD: \"92.5\"\n"
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf, err := config.NewLoader(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := direct.ClientInfo{Login: "client", Currency: "USD", VatRate: 0}
+	opts, err := moneyOptions(conf.Money, account, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := &report.Table{Columns: []string{"Date", "Cost"}, Rows: [][]string{{"2024-05-01", "10"}}}
+	result, err := money.Convert(table, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"Cost":            "10.00",
+		"Cost_WithVAT":    "925.00",
+		"Cost_WithoutVAT": "925.00",
+		"Currency":        "RUB",
+		"AccountCurrency": "USD",
+		"Rate":            "92.50",
+	}
+	for i, column := range result.Columns {
+		if value, ok := want[column]; ok && result.Rows[0][i] != value {
+			t.Errorf("%s = %q, want %q", column, result.Rows[0][i], value)
+		}
+	}
+}
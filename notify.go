@@ -0,0 +1,113 @@
+package main
+
+import (
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/config"
+	"github.com/AlekseiGrigorev/ydloader/internal/history"
+	"github.com/AlekseiGrigorev/ydloader/internal/notify"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Notifications dispatcher, nil if no notifiers are configured
+var AppNotify *notify.Dispatcher
+
+// Init notifiers configured in notify section
+func initNotify(conf config.Notify) error {
+	notifiers := []notify.Notifier{}
+	if conf.Webhook.Url != "" {
+		token, err := resolveSecret(conf.Webhook.Token)
+		if err != nil {
+			Log.Error(err, trace.GetTrace())
+			return err
+		}
+		notifiers = append(notifiers, notify.NewWebhook(conf.Webhook.Url, token))
+	}
+	if conf.Smtp.Host != "" {
+		password, err := resolveSecret(conf.Smtp.Password)
+		if err != nil {
+			Log.Error(err, trace.GetTrace())
+			return err
+		}
+		notifiers = append(notifiers, notify.NewSmtp(conf.Smtp.Host, conf.Smtp.Port,
+			conf.Smtp.Username, password, conf.Smtp.From, conf.Smtp.To))
+	}
+	if conf.Telegram.Token != "" {
+		token, err := resolveSecret(conf.Telegram.Token)
+		if err != nil {
+			Log.Error(err, trace.GetTrace())
+			return err
+		}
+		notifiers = append(notifiers, notify.NewTelegram(conf.Telegram.Url, token, conf.Telegram.ChatId))
+	}
+	if len(notifiers) == 0 {
+		return nil
+	}
+	dispatcher, err := notify.NewDispatcher(notifiers, notify.Options{
+		Events:      conf.Events,
+		RunTemplate: notify.Template(conf.Run),
+		JobTemplate: notify.Template(conf.Job),
+		RateLimit:   conf.RateLimit,
+		RateWindow:  time.Duration(conf.RateWindow) * time.Second,
+		Timeout:     time.Duration(conf.Timeout) * time.Second,
+	})
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	AppNotify = dispatcher
+	return nil
+}
+
+// Wait for notifications in progress
+func waitNotify() {
+	if AppNotify != nil {
+		AppNotify.Wait()
+	}
+}
+
+// Log notification error
+func notifyError(notifier string, err error) {
+	Log.Error("Notification failed", notifier, err)
+}
+
+// Send run end notifications
+func notifyRun(run *history.Run) {
+	if AppNotify == nil {
+		return
+	}
+	event := notify.Event{
+		Kind:          notify.EventRun,
+		Report:        run.Report,
+		RunId:         run.Id,
+		Status:        run.Status,
+		JobsTotal:     run.JobsTotal,
+		JobsSucceeded: run.JobsSucceeded,
+		JobsFailed:    run.JobsFailed,
+		QualityFailed: run.QualityFailed,
+	}
+	AppNotify.Send(event, notifyError)
+	if run.JobsFailed > 0 || run.QualityFailed > 0 {
+		event.Kind = notify.EventRunFailed
+		AppNotify.Send(event, notifyError)
+	}
+}
+
+// Send permanent job failure notification once, canceled jobs are not notified.
+// Job must be locked by caller
+func notifyJobFailed(baseStruct *BaseStruct, canceled bool) {
+	if AppNotify == nil || !baseStruct.Failed || baseStruct.failureNotified || canceled || baseStruct.Canceled {
+		return
+	}
+	baseStruct.failureNotified = true
+	AppNotify.Send(notify.Event{
+		Kind:     notify.EventJobFailed,
+		Report:   baseStruct.Report,
+		RunId:    baseStruct.RunId,
+		Login:    baseStruct.Login,
+		DateFrom: baseStruct.DateFrom,
+		DateTo:   baseStruct.DateTo,
+		Try:      baseStruct.Try,
+		Error:    baseStruct.Error,
+	}, notifyError)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/config"
+	"github.com/AlekseiGrigorev/ydloader/internal/quality"
+	"github.com/AlekseiGrigorev/ydloader/internal/report"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Error of report which failed blocking data quality rules, report is not written to tables
+var errQualityBlocked = errors.New("report is blocked by data quality rules")
+
+// Returns data quality rules of job report spec
+func jobQuality(baseStruct *BaseStruct) config.Quality {
+	spec, ok := AppConfig.Get().ReportSpec(baseStruct.Report)
+	if !ok {
+		return config.Quality{}
+	}
+	return spec.Quality
+}
+
+// Check data quality rules of job report and store daily totals of report.
+// Failed rules are recorded in job, returns errQualityBlocked if report must not be written to tables
+func checkQuality(baseStruct *BaseStruct, table *report.Table) error {
+	conf := jobQuality(baseStruct)
+	if !conf.Enabled() {
+		return nil
+	}
+	rules := quality.Rules{
+		NonEmpty:  conf.NonEmpty,
+		Key:       conf.Key,
+		Sanity:    conf.Sanity,
+		Deviation: conf.Deviation,
+		Columns:   conf.Columns,
+	}
+	daily, err := quality.DailyTotals(table, rules, baseStruct.DateFrom, baseStruct.DateTo)
+	if err != nil {
+		jobLog(baseStruct).Error(err, trace.GetTrace())
+		return err
+	}
+	// Non-empty and deviation rules compare report with stored totals of previous day
+	stored := rules.NonEmpty || rules.Deviation > 0
+	ctx := context.Background()
+	store := quality.NewStore(&AppDb, AppConfig.Get().Environment)
+	var previous quality.Totals
+	if stored {
+		from, err := time.Parse(report.DateFormat, baseStruct.DateFrom)
+		if err != nil {
+			return err
+		}
+		previous, err = store.Totals(ctx, baseStruct.Report, baseStruct.Login, from.AddDate(0, 0, -1).Format(report.DateFormat))
+		if err != nil {
+			jobLog(baseStruct).Error(err, trace.GetTrace())
+			return err
+		}
+	}
+	checkErr := quality.Check(table, rules, daily, previous)
+	if checkErr != nil {
+		message := strings.ReplaceAll(checkErr.Error(), "\n", "; ")
+		jobLog(baseStruct).Warn("Quality check failed", message)
+		baseStruct.mu.Lock()
+		baseStruct.QualityError = message
+		if conf.Block {
+			baseStruct.Failed = true
+			baseStruct.Error = "quality: " + message
+		}
+		baseStruct.mu.Unlock()
+		if conf.Block {
+			return errQualityBlocked
+		}
+	}
+	if stored {
+		err = store.Save(ctx, baseStruct.Report, baseStruct.Login, daily)
+		if err != nil {
+			jobLog(baseStruct).Error(err, trace.GetTrace())
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/config"
+	"github.com/AlekseiGrigorev/ydloader/internal/reload"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Delay to collect file changes before reload
+const ReloadDebounce = 500 * time.Millisecond
+
+// Watch config file and templates until context is done.
+// Valid changes are applied to new jobs, broken changes are rejected and logged
+func startWatch(ctx context.Context) {
+	paths := []string{AppTemplates.Dir()}
+	if AppConfigFile != "" {
+		paths = append(paths, AppConfigFile)
+	}
+	watcher, err := reload.New(paths, ReloadDebounce, onFilesChanged)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return
+	}
+	go watcher.Run(ctx)
+	Log.Info("Watching for changes", paths)
+}
+
+// Reload changed config file and templates
+func onFilesChanged(changed []string) {
+	configFile, _ := filepath.Abs(AppConfigFile)
+	configChanged := false
+	templatesChanged := false
+	for _, path := range changed {
+		if AppConfigFile != "" && path == configFile {
+			configChanged = true
+		} else {
+			templatesChanged = true
+		}
+	}
+	if templatesChanged {
+		reloadTemplates()
+	}
+	if configChanged {
+		reloadConfig()
+	}
+}
+
+// Reload templates, broken templates keep last valid version
+func reloadTemplates() {
+	err := AppTemplates.Load()
+	if err != nil {
+		Log.Error("Templates change rejected:", err)
+		return
+	}
+	Log.Info("Templates reloaded")
+}
+
+// Reload config file, invalid config is rejected
+func reloadConfig() {
+	appConfig, err := getConfig(AppConfigFile)
+	if err != nil {
+		Log.Error("Configuration change rejected:", err)
+		return
+	}
+	oldConfig := AppConfig.Get()
+	warnRestartRequired(oldConfig, appConfig)
+	// Environment is kept until restart, so history, schedules and logs
+	// of a running process never mix sandbox and production data
+	appConfig.Environment = oldConfig.Environment
+	appConfig.Sandbox = oldConfig.Sandbox
+	err = Log.Configure(appConfig.Log.Format, appConfig.Log.Level)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+	}
+	AppConfig.Set(appConfig)
+	Log.Info("Configuration reloaded", AppConfigFile)
+}
+
+// Log sections changes which are applied only on restart
+func warnRestartRequired(old config.Config, new config.Config) {
+	if old.Environment != new.Environment || !reflect.DeepEqual(old.Sandbox, new.Sandbox) {
+		Log.Info("Configuration environment changed, restart required to apply")
+	}
+	if old.Db != new.Db {
+		Log.Info("Configuration section db changed, restart required to apply")
+	}
+	if old.Secrets != new.Secrets {
+		Log.Info("Configuration section secrets changed, restart required to apply")
+	}
+	if old.OAuth != new.OAuth {
+		Log.Info("Configuration section oauth changed, restart required to apply")
+	}
+	if old.History != new.History {
+		Log.Info("Configuration section history changed, restart required to apply")
+	}
+	if old.Admin != new.Admin {
+		Log.Info("Configuration section admin changed, restart required to apply")
+	}
+	if !reflect.DeepEqual(old.Notify, new.Notify) {
+		Log.Info("Configuration section notify changed, restart required to apply")
+	}
+	if old.Metrics != new.Metrics {
+		Log.Info("Configuration section metrics changed, restart required to apply")
+	}
+	if !slices.Equal(old.Log.Outputs, new.Log.Outputs) || old.Log.File != new.Log.File || old.Log.Syslog != new.Log.Syslog {
+		Log.Info("Configuration log outputs changed, restart required to apply")
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/report"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Run reprocess command: reprocess [-logins a,b] [-report name] [-type REPORT_TYPE] [-datefrom YYYY-MM-DD] [-dateto YYYY-MM-DD]
+// Writes tables of stored successful responses again, report API is not called.
+// Responses are selected by login, report spec, report type and report dates overlapping date range
+func runReprocess(args []string) error {
+	flags := flag.NewFlagSet("reprocess", flag.ContinueOnError)
+	logins := flags.String("logins", "", "comma separated logins, all logins if empty")
+	reportName := flags.String("report", "", "report spec name, all report specs if empty")
+	reportType := flags.String("type", "", "report type, e.g. AD_PERFORMANCE_REPORT, all types if empty")
+	dateFrom := flags.String("datefrom", "", "first report date YYYY-MM-DD")
+	dateTo := flags.String("dateto", "", "last report date YYYY-MM-DD")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	opts := LoadOptions{DateFrom: *dateFrom, DateTo: *dateTo}
+	if *logins != "" {
+		opts.Logins = strings.Split(*logins, ",")
+	}
+	err = opts.Validate()
+	if err != nil {
+		return err
+	}
+	tokens, err := reprocessTokens(opts)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	files, err := filepath.Glob(filepath.Join(inputDir(), "*", "*.txt"))
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	processed, failed := 0, 0
+	for _, file := range files {
+		if !opts.hasLogin(filepath.Base(filepath.Dir(file))) {
+			continue
+		}
+		resp, err := readResponse(file)
+		if err != nil {
+			Log.Warn("Response skipped", file, err)
+			continue
+		}
+		request := resp.Request
+		if resp.StatusCode != 200 || request == nil || !opts.hasLogin(request.Login) {
+			continue
+		}
+		if *reportName != "" && request.Report != *reportName {
+			continue
+		}
+		if (opts.DateFrom != "" && request.DateTo < opts.DateFrom) || (opts.DateTo != "" && request.DateFrom > opts.DateTo) {
+			continue
+		}
+		if *reportType != "" && requestReportType(request.Body) != *reportType {
+			continue
+		}
+		baseStruct := &BaseStruct{
+			Report:     request.Report,
+			Token:      tokens[request.Login],
+			Login:      request.Login,
+			ReportName: request.ReportName,
+			DateFrom:   request.DateFrom,
+			DateTo:     request.DateTo,
+			Headers:    request.Headers,
+			Body:       request.Body,
+			Processed:  true,
+			FilePath:   file,
+		}
+		err = writeTables(baseStruct, file, resp)
+		if err != nil {
+			failed++
+			jobLog(baseStruct).Error("Reprocess failed", file, err)
+			continue
+		}
+		processed++
+		jobLog(baseStruct).Info("Reprocessed", file)
+	}
+	fmt.Printf("reprocessed %d responses, %d failed\n", processed, failed)
+	if failed > 0 {
+		return fmt.Errorf("reprocess failed for %d responses", failed)
+	}
+	return nil
+}
+
+// Returns tokens of logins, tokens are used by money table only
+func reprocessTokens(opts LoadOptions) (map[string]string, error) {
+	tokens := map[string]string{}
+	if !AppConfig.Get().Money.Table {
+		return tokens, nil
+	}
+	logins, err := loginTokens(opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, login := range logins {
+		tokens[login.Login] = login.Token
+	}
+	return tokens, nil
+}
+
+// Returns stored response of file
+func readResponse(file string) (*RespStruct, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	resp := &RespStruct{}
+	err = json.Unmarshal(content, resp)
+	if err != nil {
+		return nil, errors.New("not a stored response")
+	}
+	return resp, nil
+}
+
+// Returns report type of report request body
+func requestReportType(body string) string {
+	request := report.Request{}
+	json.Unmarshal([]byte(body), &request)
+	return request.Params.ReportType
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+	"github.com/AlekseiGrigorev/ydloader/models/ydirectlogins"
+)
+
+// Input data subdirectory of sandbox environment
+const sandboxDir = "sandbox/"
+
+// Returns input data directory of environment, sandbox data is written to separate directory
+func inputDir() string {
+	if AppConfig.Get().IsSandbox() {
+		return InputDir + sandboxDir
+	}
+	return InputDir
+}
+
+// Returns sandbox logins with sandbox token from configuration
+func getSandboxLogins() ([]*ydirectlogins.AllIntegrationsLogin, error) {
+	conf := AppConfig.Get().Sandbox
+	token, err := resolveSecret(conf.Token)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return nil, err
+	}
+	logins := []*ydirectlogins.AllIntegrationsLogin{}
+	for _, login := range conf.Logins {
+		logins = append(logins, &ydirectlogins.AllIntegrationsLogin{Login: login, Token: token})
+	}
+	return logins, nil
+}
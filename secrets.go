@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/config"
+	"github.com/AlekseiGrigorev/ydloader/internal/secret"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Default environment variable with tokens encryption key
+const TokenKeyEnv = "YDLOADER_TOKEN_KEY"
+
+// Returns secret resolver for secrets config
+func newSecretResolver(conf config.Secrets) (*secret.Resolver, error) {
+	resolver := secret.NewResolver()
+	if conf.Vault.Address != "" {
+		token := conf.Vault.Token
+		if token == "" {
+			token = os.Getenv("VAULT_TOKEN")
+		}
+		timeout := time.Duration(conf.Vault.Timeout) * time.Second
+		resolver.Register("vault", secret.NewVaultProvider(conf.Vault.Address, token, conf.Vault.Mount, timeout))
+	}
+	cipher, err := newCipher(conf)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return nil, err
+	}
+	resolver.SetCipher(cipher)
+	return resolver, nil
+}
+
+// Returns tokens cipher with key from environment or file, nil if key is not configured
+func newCipher(conf config.Secrets) (*secret.Cipher, error) {
+	keyEnv := conf.KeyEnv
+	if keyEnv == "" {
+		keyEnv = TokenKeyEnv
+	}
+	keyText := os.Getenv(keyEnv)
+	if keyText == "" && conf.KeyFile != "" {
+		b, err := os.ReadFile(conf.KeyFile)
+		if err != nil {
+			Log.Error(err, trace.GetTrace())
+			return nil, err
+		}
+		keyText = string(b)
+	}
+	if keyText == "" {
+		return nil, nil
+	}
+	key, err := secret.ParseKey(keyText)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return nil, err
+	}
+	return secret.NewCipher(key)
+}
+
+// Resolve secret value of config
+func resolveSecret(value string) (string, error) {
+	if AppSecrets == nil {
+		return value, nil
+	}
+	return AppSecrets.Resolve(context.Background(), value)
+}
+
+// Resolve secret value stored in DB, e.g. integration token. Only plain and encrypted values are allowed
+func resolveStoredSecret(value string) (string, error) {
+	if AppSecrets == nil {
+		return value, nil
+	}
+	return AppSecrets.ResolveStored(context.Background(), value)
+}
+
+// Run secret command: secret genkey | encrypt
+// encrypt reads plain value from stdin and prints encrypted value
+func runSecret(args []string) error {
+	flags := flag.NewFlagSet("secret", flag.ContinueOnError)
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	switch flags.Arg(0) {
+	case "genkey":
+		key, err := secret.GenerateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(key)
+		return nil
+	case "encrypt":
+		cipher, err := newCipher(AppConfig.Get().Secrets)
+		if err != nil {
+			return err
+		}
+		if cipher == nil {
+			return errors.New("encryption key is not configured")
+		}
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return err
+		}
+		encrypted, err := cipher.Encrypt(strings.TrimRight(line, "\r\n"))
+		if err != nil {
+			return err
+		}
+		fmt.Println(encrypted)
+		return nil
+	}
+	return errors.New("usage: ydloader secret genkey | encrypt")
+}
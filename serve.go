@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/config"
+	"github.com/AlekseiGrigorev/ydloader/internal/cron"
+	"github.com/AlekseiGrigorev/ydloader/internal/schedule"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Error of report spec which is already running
+var ErrReportRunning = errors.New("report is already running")
+
+// Report spec locks of this process
+var reportLocks = map[string]*sync.Mutex{}
+var reportLocksMu sync.Mutex
+
+// Lock report spec to prevent overlapping runs in this process and in other processes.
+// Returns unlock function or ErrReportRunning
+func lockReport(ctx context.Context, name string) (func(), error) {
+	reportLocksMu.Lock()
+	lock, ok := reportLocks[name]
+	if !ok {
+		lock = &sync.Mutex{}
+		reportLocks[name] = lock
+	}
+	reportLocksMu.Unlock()
+	if !lock.TryLock() {
+		return nil, ErrReportRunning
+	}
+	unlockDb, locked, err := AppDb.TryLock(ctx, "ydloader:report:"+name)
+	if err != nil {
+		lock.Unlock()
+		Log.Error(err, trace.GetTrace())
+		return nil, err
+	}
+	if !locked {
+		lock.Unlock()
+		return nil, ErrReportRunning
+	}
+	return func() {
+		unlockDb()
+		lock.Unlock()
+	}, nil
+}
+
+// Run load command: load [-report name] [-logins a,b] [-datefrom YYYY-MM-DD] [-dateto YYYY-MM-DD] [-changes]
+// Loads report spec once, first report spec is used if name is not set
+func runLoad(args []string) error {
+	spec, opts, err := parseLoadFlags(args)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	startWatch(ctx)
+	return load(ctx, spec, opts)
+}
+
+// Returns report spec and load options from load command flags
+func parseLoadFlags(args []string) (config.Report, LoadOptions, error) {
+	flags := flag.NewFlagSet("load", flag.ContinueOnError)
+	name := flags.String("report", "", "report spec name")
+	logins := flags.String("logins", "", "comma separated logins to load, all logins if empty")
+	dateFrom := flags.String("datefrom", "", "first report date YYYY-MM-DD, report spec date if empty")
+	dateTo := flags.String("dateto", "", "last report date YYYY-MM-DD, report spec date if empty")
+	changes := flags.Bool("changes", false, "load only changed campaigns and dates")
+	err := flags.Parse(args)
+	if err != nil {
+		return config.Report{}, LoadOptions{}, err
+	}
+	spec, ok := AppConfig.Get().ReportSpec(*name)
+	if !ok {
+		return config.Report{}, LoadOptions{}, errors.New("report spec not found: " + *name)
+	}
+	spec.Changes = spec.Changes || *changes
+	opts := LoadOptions{DateFrom: *dateFrom, DateTo: *dateTo}
+	if *logins != "" {
+		opts.Logins = strings.Split(*logins, ",")
+	}
+	err = opts.Validate()
+	if err != nil {
+		return config.Report{}, LoadOptions{}, err
+	}
+	return spec, opts, nil
+}
+
+// Scheduled report spec state
+type scheduled struct {
+	expr     string
+	schedule *cron.Schedule
+	next     time.Time
+}
+
+// Scheduler runs report specs by cron schedules
+type scheduler struct {
+	store   *schedule.Store
+	reports map[string]*scheduled
+	wg      sync.WaitGroup
+}
+
+// Run serve command: serve
+// Runs report specs by schedules until interrupted
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	startWatch(ctx)
+	s := &scheduler{
+		store:   schedule.NewStore(&AppDb, AppConfig.Get().Environment),
+		reports: map[string]*scheduled{},
+	}
+	startMetrics(ctx)
+	err = startAdmin(ctx, &s.wg)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	Log.Info("Serve started")
+	if AppConfig.Get().Serve.CatchUp {
+		s.catchUp(ctx, time.Now())
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			Log.Info("Serve stopping, waiting for running loads")
+			s.wg.Wait()
+			Log.Info("Serve stopped")
+			return nil
+		case now := <-ticker.C:
+			s.tick(ctx, now)
+		}
+	}
+}
+
+// Returns schedules location
+func scheduleLocation() *time.Location {
+	loc, err := time.LoadLocation(AppConfig.Get().Serve.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// Run report specs which missed their schedules after downtime, once per spec
+func (s *scheduler) catchUp(ctx context.Context, now time.Time) {
+	now = now.In(scheduleLocation())
+	for _, spec := range AppConfig.Get().ReportSpecs() {
+		if spec.Schedule == "" {
+			continue
+		}
+		sched, err := cron.Parse(spec.Schedule)
+		if err != nil {
+			Log.Error(err, trace.GetTrace())
+			continue
+		}
+		lastRun, ok, err := s.store.LastRun(ctx, spec.Name)
+		if err != nil {
+			Log.Error(err, trace.GetTrace())
+			continue
+		}
+		if !ok {
+			// First start: nothing is missed
+			err = s.store.SetLastRun(ctx, spec.Name, now)
+			if err != nil {
+				Log.Error(err, trace.GetTrace())
+			}
+			continue
+		}
+		missed := sched.Next(lastRun.In(now.Location()))
+		if missed.IsZero() || missed.After(now) {
+			continue
+		}
+		Log.Info("Report missed schedule, catch up", spec.Name, missed)
+		s.start(ctx, spec, missed)
+	}
+}
+
+// Start report specs which schedule time has come
+func (s *scheduler) tick(ctx context.Context, now time.Time) {
+	now = now.In(scheduleLocation())
+	specs := AppConfig.Get().ReportSpecs()
+	active := map[string]bool{}
+	for _, spec := range specs {
+		if spec.Schedule == "" {
+			continue
+		}
+		active[spec.Name] = true
+		item, ok := s.reports[spec.Name]
+		// New report spec or schedule changed by config reload
+		if !ok || item.expr != spec.Schedule {
+			sched, err := cron.Parse(spec.Schedule)
+			if err != nil {
+				Log.Error(err, trace.GetTrace())
+				continue
+			}
+			item = &scheduled{expr: spec.Schedule, schedule: sched, next: sched.Next(now)}
+			s.reports[spec.Name] = item
+			Log.Info("Report scheduled", spec.Name, spec.Schedule, "next run", item.next)
+			continue
+		}
+		if item.next.IsZero() || now.Before(item.next) {
+			continue
+		}
+		scheduledAt := item.next
+		item.next = item.schedule.Next(now)
+		s.start(ctx, spec, scheduledAt)
+	}
+	for name := range s.reports {
+		if !active[name] {
+			delete(s.reports, name)
+			Log.Info("Report unscheduled", name)
+		}
+	}
+}
+
+// Start report spec load in background.
+// Last run is stored after successful load only, so skipped and failed runs are caught up after restart
+func (s *scheduler) start(ctx context.Context, spec config.Report, scheduledAt time.Time) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := load(ctx, spec, LoadOptions{})
+		if errors.Is(err, ErrReportRunning) {
+			Log.Info("Report skipped, previous run is not finished", spec.Name, scheduledAt)
+			return
+		}
+		if err != nil {
+			Log.Error("Report load failed", spec.Name, err)
+			return
+		}
+		Log.Info("Report load finished", spec.Name, scheduledAt)
+		// Load may finish after serve is stopped
+		err = s.store.SetLastRun(context.Background(), spec.Name, scheduledAt)
+		if err != nil {
+			Log.Error(err, trace.GetTrace())
+		}
+	}()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/direct"
+	"github.com/AlekseiGrigorev/ydloader/internal/timezones"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+// Detect timezones by API, API is not called in dry run
+var detectTimezones = true
+
+// Locations of logins, cached for the run
+var loginLocations = map[string]*time.Location{}
+var loginLocationsMu sync.Mutex
+
+// Reset cached login locations before new run
+func resetLocations() {
+	loginLocationsMu.Lock()
+	defer loginLocationsMu.Unlock()
+	loginLocations = map[string]*time.Location{}
+}
+
+// Returns account location of login: timezone of config, database or campaigns, default timezone if unknown
+func loginLocation(login string, token string) *time.Location {
+	loginLocationsMu.Lock()
+	defer loginLocationsMu.Unlock()
+	if loc, ok := loginLocations[login]; ok {
+		return loc
+	}
+	conf := AppConfig.Get().Timezones
+	timezone, err := loginTimezone(login, token)
+	if err != nil {
+		Log.Error(err, "login", login, trace.GetTrace())
+	}
+	if timezone == "" {
+		timezone = conf.Default
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		Log.Error(err, "login", login, trace.GetTrace())
+		loc, _ = time.LoadLocation(conf.Default)
+	}
+	loginLocations[login] = loc
+	return loc
+}
+
+// Returns timezone of login from config, database or campaigns, empty if unknown
+func loginTimezone(login string, token string) (string, error) {
+	conf := AppConfig.Get().Timezones
+	// Config keys are lowercase
+	if timezone, ok := conf.Logins[strings.ToLower(login)]; ok {
+		return timezone, nil
+	}
+	ctx := context.Background()
+	store := timezones.NewStore(&AppDb)
+	if conf.Db {
+		timezone, err := store.Get(ctx, login)
+		if err != nil || timezone != "" {
+			return timezone, err
+		}
+	}
+	if !conf.Api || !detectTimezones {
+		return "", nil
+	}
+	timeout := time.Duration(AppConfig.Get().Http.Timeout) * time.Second
+	client := direct.NewClient(AppConfig.Get().ApiUrl(), token, timeout).WithLogin(login)
+	timezone, err := client.Timezone(ctx)
+	if err != nil || timezone == "" {
+		return "", err
+	}
+	Log.Info("Timezone detected", "login", login, timezone)
+	if conf.Db {
+		err = store.Set(ctx, login, timezone)
+	}
+	return timezone, err
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/config"
+)
+
+func TestLoginDates(t *testing.T) {
+	AppConfig.Set(config.Config{Timezones: config.Timezones{
+		Default: "UTC",
+		Logins:  map[string]string{"ahead": "Asia/Vladivostok", "behind": "America/Los_Angeles"},
+	}})
+	resetLocations()
+	defer resetLocations()
+	spec := config.Report{DateFrom: -1, DateTo: -1}
+	tests := []struct {
+		name   string
+		login  string
+		server time.Time
+		want   string
+	}{
+		{"zone ahead, just after login midnight", "Ahead", time.Date(2024, 5, 1, 15, 30, 0, 0, time.UTC), "2024-05-01"},
+		{"zone ahead, before login midnight", "Ahead", time.Date(2024, 5, 1, 13, 30, 0, 0, time.UTC), "2024-04-30"},
+		{"zone behind, just after server midnight", "Behind", time.Date(2024, 5, 2, 0, 30, 0, 0, time.UTC), "2024-04-30"},
+		{"zone behind, just after login midnight", "Behind", time.Date(2024, 5, 2, 7, 30, 0, 0, time.UTC), "2024-05-01"},
+		{"default zone", "other", time.Date(2024, 5, 2, 0, 30, 0, 0, time.UTC), "2024-05-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dateFrom, dateTo := reportDates(spec, tt.server.In(loginLocation(tt.login, "")))
+			if dateFrom != tt.want || dateTo != tt.want {
+				t.Errorf("dates of %s at %s = %s..%s, want %s", tt.login, tt.server, dateFrom, dateTo, tt.want)
+			}
+		})
+	}
+}
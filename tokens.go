@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlekseiGrigorev/ydloader/internal/direct"
+	"github.com/AlekseiGrigorev/ydloader/internal/oauth"
+	"github.com/AlekseiGrigorev/ydloader/internal/secret"
+	"github.com/AlekseiGrigorev/ydloader/internal/tokens"
+	"github.com/AlekseiGrigorev/ydloader/internal/trace"
+)
+
+var AppTokens tokens.Source
+var AppOAuth *oauth.Client
+
+// Current access tokens by integration id
+var currentTokens = map[int]string{}
+var currentTokensMu sync.Mutex
+
+// Token refresh of integration, jobs of integration wait for refresh started by other job
+type tokenRefresh struct {
+	done  chan struct{}
+	token string // New access token, empty if refresh failed
+}
+
+// Token refreshes in progress by integration id, guarded by currentTokensMu
+var tokenRefreshes = map[int]*tokenRefresh{}
+
+// Init token source and OAuth client, OAuth client is created only if OAuth refresh is configured
+func initTokens() error {
+	AppTokens = tokens.NewStore(&AppDb)
+	oauthConfig := AppConfig.Get().OAuth
+	if !oauthConfig.Enabled {
+		return nil
+	}
+	clientSecret, err := resolveSecret(oauthConfig.ClientSecret)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	timeout := time.Duration(oauthConfig.Timeout) * time.Second
+	AppOAuth = oauth.NewClient(oauthConfig.TokenUrl, oauthConfig.ClientId, clientSecret, timeout)
+	return nil
+}
+
+// Reset current access tokens before new run, so tokens changed in token source are used
+func resetCurrentTokens() {
+	currentTokensMu.Lock()
+	defer currentTokensMu.Unlock()
+	currentTokens = map[int]string{}
+}
+
+// Returns token for integration.
+// Token refreshed by OAuth flow overrides token from integrations, expired token is refreshed.
+// Token is resolved without lock, so jobs don't wait for token source and refresh of other integrations
+func effectiveToken(integrationId int, token string) string {
+	currentTokensMu.Lock()
+	current, ok := currentTokens[integrationId]
+	currentTokensMu.Unlock()
+	if ok {
+		return current
+	}
+	resolved := storedToken(integrationId, token)
+	currentTokensMu.Lock()
+	defer currentTokensMu.Unlock()
+	// Token resolved by other job of integration in the meantime is kept
+	if current, ok := currentTokens[integrationId]; ok {
+		return current
+	}
+	currentTokens[integrationId] = resolved
+	return resolved
+}
+
+// Returns valid token of integration from token source, expired token is refreshed.
+// Token from integrations is returned if token source has no valid token
+func storedToken(integrationId int, token string) string {
+	if AppTokens == nil {
+		return token
+	}
+	ctx := context.Background()
+	state, err := AppTokens.Get(ctx, integrationId)
+	if err != nil {
+		Log.Error(err, "integration", integrationId, trace.GetTrace())
+		return token
+	}
+	if state == nil || state.AccessToken == "" {
+		return token
+	}
+	if state.Status == tokens.StatusValid && !state.Expired(time.Now()) {
+		refreshed, err := resolveStoredSecret(state.AccessToken)
+		if err != nil {
+			Log.Error(err, "integration", integrationId, trace.GetTrace())
+			return token
+		}
+		return refreshed
+	}
+	if AppOAuth == nil {
+		return token
+	}
+	Log.Info("Token expired, refresh", "integration", integrationId)
+	refreshed := refreshOnce(integrationId, func() string {
+		refreshed, err := refreshToken(ctx, integrationId)
+		if err != nil {
+			Log.Error(err, "integration", integrationId, trace.GetTrace())
+			return ""
+		}
+		return refreshed
+	})
+	if refreshed == "" {
+		return token
+	}
+	return refreshed
+}
+
+// Handle authorization error of the job: mark token invalid and refresh it.
+// One job of integration refreshes token, other jobs of integration wait for its result.
+// Must be called by request goroutine without job lock. Returns new token, empty if job can't be retried
+func handleAuthError(baseStruct *BaseStruct, apiErr *direct.Error) string {
+	// Sandbox token is not stored in token source
+	if AppConfig.Get().IsSandbox() {
+		return ""
+	}
+	baseStruct.mu.Lock()
+	id := baseStruct.IntegrationId
+	token := baseStruct.Token
+	baseStruct.mu.Unlock()
+	currentTokensMu.Lock()
+	// Token already refreshed by other job of the integration
+	if current, ok := currentTokens[id]; ok && current != "" && current != token {
+		currentTokensMu.Unlock()
+		return current
+	}
+	currentTokensMu.Unlock()
+	return refreshOnce(id, func() string {
+		return refreshInvalidToken(baseStruct, token, apiErr)
+	})
+}
+
+// Run token refresh of integration once, callers started during refresh wait for its result.
+// Refreshed token becomes current token of integration. Returns new token, empty if refresh failed
+func refreshOnce(integrationId int, refresh func() string) string {
+	currentTokensMu.Lock()
+	running, ok := tokenRefreshes[integrationId]
+	if ok {
+		currentTokensMu.Unlock()
+		<-running.done
+		return running.token
+	}
+	current := &tokenRefresh{done: make(chan struct{})}
+	tokenRefreshes[integrationId] = current
+	currentTokensMu.Unlock()
+	current.token = refresh()
+	currentTokensMu.Lock()
+	if current.token != "" {
+		currentTokens[integrationId] = current.token
+	}
+	delete(tokenRefreshes, integrationId)
+	currentTokensMu.Unlock()
+	close(current.done)
+	return current.token
+}
+
+// Mark invalid token of job integration in token source and refresh it.
+// Returns new token, empty if token is not refreshed
+func refreshInvalidToken(baseStruct *BaseStruct, token string, apiErr *direct.Error) string {
+	id := baseStruct.IntegrationId
+	reason := "authorization error"
+	if apiErr != nil {
+		reason = apiErr.Error()
+	}
+	jobLog(baseStruct).Error("Token is invalid", "integration", id, secret.Mask(token), reason)
+	ctx := context.Background()
+	if AppTokens != nil {
+		err := AppTokens.Invalidate(ctx, id, reason)
+		if err != nil {
+			jobLog(baseStruct).Error(err, trace.GetTrace())
+		}
+	}
+	if AppOAuth == nil {
+		return ""
+	}
+	refreshed, err := refreshToken(ctx, id)
+	if err != nil {
+		jobLog(baseStruct).Error(err, "integration", id, trace.GetTrace())
+		return ""
+	}
+	jobLog(baseStruct).Info("Token refreshed", "integration", id, secret.Mask(refreshed))
+	return refreshed
+}
+
+// Refresh integration token with refresh token from token source and store new token.
+// Returns new access token
+func refreshToken(ctx context.Context, integrationId int) (string, error) {
+	if AppTokens == nil || AppOAuth == nil {
+		return "", errors.New("token refresh is not configured")
+	}
+	state, err := AppTokens.Get(ctx, integrationId)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return "", err
+	}
+	if state == nil || state.RefreshToken == "" {
+		return "", errors.New("refresh token not found for integration " + strconv.Itoa(integrationId))
+	}
+	refreshToken, err := resolveStoredSecret(state.RefreshToken)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return "", err
+	}
+	token, err := AppOAuth.Refresh(ctx, refreshToken)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return "", err
+	}
+	accessToken, err := AppSecrets.Encrypt(token.AccessToken)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return "", err
+	}
+	newRefreshToken, err := AppSecrets.Encrypt(token.RefreshToken)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return "", err
+	}
+	err = AppTokens.Save(ctx, integrationId, accessToken, newRefreshToken, token.ExpiresAt)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// Set new token for job and render job headers
+func setJobToken(baseStruct *BaseStruct, token string) error {
+	header, err := AppTemplates.Get("header.json")
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	headerMap := make(map[string]string)
+	headerMap["@AuthorizationToken"] = token
+	headerMap["@Client-Login"] = baseStruct.Login
+	baseStruct.Token = token
+	baseStruct.Headers = header.Process(headerMap)
+	return nil
+}
+
+// Run token command: token status | set-refresh <integration id>
+// set-refresh reads refresh token from stdin
+func runToken(args []string) error {
+	flags := flag.NewFlagSet("token", flag.ContinueOnError)
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	store := tokens.NewStore(&AppDb)
+	switch flags.Arg(0) {
+	case "status":
+		states, err := store.List(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%-12s %-8s %-19s %-19s %-8s %s\n", "INTEGRATION", "STATUS", "INVALID SINCE", "EXPIRES AT", "REFRESH", "REASON")
+		for _, s := range states {
+			hasRefresh := "no"
+			if s.RefreshToken != "" {
+				hasRefresh = "yes"
+			}
+			fmt.Printf("%-12d %-8s %-19s %-19s %-8s %s\n", s.IntegrationId, s.Status, s.InvalidSince, s.ExpiresAt, hasRefresh, s.Reason)
+		}
+		return nil
+	case "set-refresh":
+		id, err := strconv.Atoi(flags.Arg(1))
+		if err != nil {
+			return errors.New("usage: ydloader token set-refresh <integration id>")
+		}
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return err
+		}
+		refreshToken, err := AppSecrets.Encrypt(strings.TrimRight(line, "\r\n"))
+		if err != nil {
+			return err
+		}
+		return store.SetRefreshToken(ctx, id, refreshToken)
+	}
+	return errors.New("usage: ydloader token status | set-refresh <integration id>")
+}
@@ -2,117 +2,419 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
-	"log"
-	"math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/spf13/viper"
-
 	"github.com/AlekseiGrigorev/ydloader/internal/config"
 	"github.com/AlekseiGrigorev/ydloader/internal/db"
+	"github.com/AlekseiGrigorev/ydloader/internal/direct"
+	"github.com/AlekseiGrigorev/ydloader/internal/history"
 	"github.com/AlekseiGrigorev/ydloader/internal/logger"
+	"github.com/AlekseiGrigorev/ydloader/internal/report"
+	"github.com/AlekseiGrigorev/ydloader/internal/secret"
 	"github.com/AlekseiGrigorev/ydloader/internal/template"
+	"github.com/AlekseiGrigorev/ydloader/internal/timezones"
 	"github.com/AlekseiGrigorev/ydloader/internal/trace"
 	"github.com/AlekseiGrigorev/ydloader/models/integrations"
 	"github.com/AlekseiGrigorev/ydloader/models/ydirectlogins"
 )
 
-const IntegrationId = 0     //10472 - 50, 7101 - 34
-const InputDir = "./input/" //Input data dir (getting from api)
-const LogFile = "app.log"
+const IntegrationId = 0             //10472 - 50, 7101 - 34
+const InputDir = "./input/"         //Input data dir (getting from api)
+const TemplatesDir = "./templates/" //Request templates dir
 
-var AppConfig config.Config
+var AppConfig config.Holder
+var AppConfigFile string
+var AppTemplates = template.NewStore(TemplatesDir)
 var AppDb db.Db
-var Log = logger.Log{
-	PrintToStdout:   true,
-	PrefixDelimiter: " ",
-}
+var AppHistory *history.Store
+var AppSecrets *secret.Resolver
+var Log = logger.New()
 
 type BaseStruct struct {
-	Token     string
-	Login     string
-	Headers   string
-	Body      string
-	Processed bool
-	Started   bool
-	NextTry   time.Time
-	Try       int
+	mu            sync.Mutex // Guards job state shared by jobs runner, request goroutine and admin API
+	Id            int64
+	Report        string
+	RunId         int64
+	IntegrationId int
+	Token         string
+	Login         string
+	ReportName    string
+	DateFrom      string
+	DateTo        string
+	Headers       string
+	Body          string
+	Processed     bool
+	Started       bool
+	Failed        bool
+	Error         string
+	NextTry       time.Time
+	Try           int
+	RequestId     string
+	FirstTry      time.Time
+	FilePath      string
+	Canceled      bool
+
+	ChangesTimestamp string // Changes service timestamp stored after job is done, changes mode only
+	QualityError     string // Failed data quality rules of report
+	failureNotified  bool
 }
 
 type RespStruct struct {
-	Status     string
-	StatusCode int
-	Header     http.Header
+	Environment string // production or sandbox, sandbox data must not be mixed with production data
+	Status      string
+	StatusCode  int
+	Header      http.Header
+	Body        string
+	Request     *RequestStruct // Request of response, used by reprocess
+}
+
+// Report request of stored response, token is masked
+type RequestStruct struct {
+	Report     string
+	Login      string
+	ReportName string
+	DateFrom   string
+	DateTo     string
+	Headers    string
 	Body       string
 }
 
 func main() {
-	Log.Log().SetFlags(log.LstdFlags)
-	AppConfig = getConfig()
+	os.Exit(run())
+}
+
+// Run application, returns exit code
+func run() int {
+	flags := flag.NewFlagSet("ydloader", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv(config.EnvConfigFile), "configuration file path")
+	dryRun := flags.Bool("dry-run", false, "render and validate report requests of load without calling API")
+	dryRunOut := flags.String("dry-run-out", "", "directory to write dry run requests, stdout if empty")
+	err := flags.Parse(os.Args[1:])
+	if err != nil {
+		return 2
+	}
+	args := flags.Args()
 
-	file, err := os.OpenFile(LogFile, os.O_CREATE|os.O_APPEND, 0777)
+	// Config command works without valid configuration, database and log file
+	if len(args) > 0 && args[0] == "config" {
+		err = runConfig(*configFile, args[1:])
+		if err != nil {
+			Log.Error(err)
+			return 1
+		}
+		return 0
+	}
+
+	appConfig, err := getConfig(*configFile)
 	if err != nil {
-		Log.Error("Failed to open log file:", err)
-		return
+		Log.Error(err)
+		return 1
+	}
+	AppConfig.Set(appConfig)
+
+	err = Log.Configure(appConfig.Log.Format, appConfig.Log.Level)
+	if err != nil {
+		Log.Error(err)
+		return 1
+	}
+	closeLog, err := openLogOutputs(appConfig.Log)
+	if err != nil {
+		Log.Error("Failed to open log outputs:", err)
+		return 1
+	}
+	defer closeLog()
+	if appConfig.IsSandbox() {
+		// Every record of sandbox run is tagged
+		Log = Log.With("environment", config.EnvironmentSandbox)
 	}
-	defer file.Close()
-	Log.Log().SetOutput(file)
 	Log.Info("App started")
 
-	AppDb.Init(AppConfig.Db.Username, AppConfig.Db.Password, AppConfig.Db.Host, AppConfig.Db.Port, AppConfig.Db.Database)
+	err = AppTemplates.Load()
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return 1
+	}
+
+	AppSecrets, err = newSecretResolver(appConfig.Secrets)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return 1
+	}
+	dbConfig := appConfig.Db
+	dbConfig.Password, err = resolveSecret(dbConfig.Password)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return 1
+	}
 
-	var structs []*BaseStruct
+	err = AppDb.Init(dbConfig)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return 1
+	}
+	defer AppDb.Close()
 
-	if IntegrationId > 0 {
-		token, err := getToken(IntegrationId)
+	if *dryRun {
+		err = runDryRun(args, *dryRunOut)
 		if err != nil {
-			Log.Error(err, trace.GetTrace())
-			return
+			Log.Error(err)
+			return 1
 		}
+		return 0
+	}
 
-		Log.Info(token)
+	if appConfig.History.Enabled {
+		AppHistory = history.NewStore(&AppDb, appConfig.Environment)
+	}
+	err = initNotify(appConfig.Notify)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return 1
+	}
+	defer waitNotify()
+	err = initTokens()
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return 1
+	}
 
-		logins, err := getLogins(IntegrationId)
+	if len(args) > 0 {
+		err = runCommand(args[0], args[1:])
 		if err != nil {
 			Log.Error(err, trace.GetTrace())
-			return
+			return 1
 		}
+		return 0
+	}
 
-		for _, login := range logins {
-			Log.Info(*login)
+	err = runLoad(nil)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return 1
+	}
+	return 0
+}
+
+// Run command by name
+func runCommand(name string, args []string) error {
+	switch name {
+	case "migrate":
+		return runMigrate(args)
+	case "history":
+		return runHistory(args)
+	case "secret":
+		return runSecret(args)
+	case "token":
+		return runToken(args)
+	case "load":
+		return runLoad(args)
+	case "serve":
+		return runServe(args)
+	case "discover":
+		return runDiscover(args)
+	case "dimensions":
+		return runDimensions(args)
+	case "reprocess":
+		return runReprocess(args)
+	}
+	return errors.New("unknown command: " + name)
+}
+
+// Load options overriding report spec
+type LoadOptions struct {
+	Logins   []string // Load only these logins, all logins if empty
+	DateFrom string   // First report date YYYY-MM-DD, report spec date if empty
+	DateTo   string   // Last report date YYYY-MM-DD, report spec date if empty
+}
+
+// Returns true if login should be loaded
+func (o LoadOptions) hasLogin(login string) bool {
+	return len(o.Logins) == 0 || slices.Contains(o.Logins, login)
+}
+
+// Returns report dates: options dates or report spec dates relative to now
+func (o LoadOptions) dates(spec config.Report, now time.Time) (string, string) {
+	dateFrom, dateTo := reportDates(spec, now)
+	if o.DateFrom != "" {
+		dateFrom = o.DateFrom
+	}
+	if o.DateTo != "" {
+		dateTo = o.DateTo
+	}
+	return dateFrom, dateTo
+}
+
+// Validate returns error if options dates are invalid
+func (o LoadOptions) Validate() error {
+	var from, to time.Time
+	var err error
+	if o.DateFrom != "" {
+		from, err = time.Parse("2006-01-02", o.DateFrom)
+		if err != nil {
+			return fmt.Errorf("invalid datefrom %q, expected YYYY-MM-DD", o.DateFrom)
+		}
+	}
+	if o.DateTo != "" {
+		to, err = time.Parse("2006-01-02", o.DateTo)
+		if err != nil {
+			return fmt.Errorf("invalid dateto %q, expected YYYY-MM-DD", o.DateTo)
 		}
+	}
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		return errors.New("datefrom must not be after dateto")
+	}
+	return nil
+}
+
+// Error of load with failed or unfinished jobs
+var ErrJobsFailed = errors.New("report jobs failed")
 
-		structs, err = fillBaseStructs(token, logins)
+// Load report spec data for all logins
+func load(ctx context.Context, spec config.Report, opts LoadOptions) error {
+	unlock, err := lockReport(ctx, spec.Name)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	defer unlock()
+	return loadLocked(ctx, spec, opts)
+}
+
+// Load report spec data, report spec must be locked by caller
+func loadLocked(ctx context.Context, spec config.Report, opts LoadOptions) error {
+	Log.Info("Load report", spec.Name)
+
+	structs, err := createJobs(spec, opts)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	if len(structs) == 0 {
+		err = errors.New("no logins to load")
+		Log.Error(err, trace.GetTrace())
+		return err
+	}
+	if spec.Changes {
+		structs = applyChanges(ctx, spec, structs)
+		if len(structs) == 0 {
+			Log.Info("No changes to load", spec.Name)
+			return nil
+		}
+	}
+
+	runId := startRun(spec.Name)
+	for _, baseStruct := range structs {
+		baseStruct.RunId = runId
+	}
+	AppJobs.Set(spec.Name, structs)
+	defer finishRun(spec.Name, runId, structs)
+
+	applyCache(structs)
+	runJobs(ctx, structs)
+	if spec.Changes {
+		saveChanges(spec.Name, structs)
+	}
+	failed := 0
+	for _, baseStruct := range structs {
+		baseStruct.mu.Lock()
+		if !baseStruct.Processed || baseStruct.Failed {
+			failed++
+		}
+		baseStruct.mu.Unlock()
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d jobs", ErrJobsFailed, failed, len(structs))
+	}
+	return nil
+}
+
+// Create jobs of report spec for logins
+func createJobs(spec config.Report, opts LoadOptions) ([]*BaseStruct, error) {
+	resetAccounts()
+	resetLocations()
+	structs, err := createLoginJobs(spec, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = applyGoals(spec, structs)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return nil, err
+	}
+	for _, baseStruct := range structs {
+		err = setReportName(baseStruct)
 		if err != nil {
 			Log.Error(err, trace.GetTrace())
-			return
+			return nil, err
 		}
-	} else {
-		logins, err := getAllLogins()
+	}
+	return structs, nil
+}
+
+// Create jobs of report spec for logins of login source
+func createLoginJobs(spec config.Report, opts LoadOptions) ([]*BaseStruct, error) {
+	resetCurrentTokens()
+
+	if AppConfig.Get().IsSandbox() {
+		logins, err := getSandboxLogins()
 		if err != nil {
 			Log.Error(err, trace.GetTrace())
-			return
+			return nil, err
 		}
+		return fillBaseStructsForAllLogins(spec, opts, logins)
+	}
 
-		for _, login := range logins {
-			Log.Info(*login)
+	if IntegrationId > 0 {
+		token, err := getToken(IntegrationId)
+		if err != nil {
+			Log.Error(err, trace.GetTrace())
+			return nil, err
 		}
 
-		structs, err = fillBaseStructsForAllLogins(logins)
+		Log.Info("Token", secret.Mask(token))
+
+		logins, err := getLogins(IntegrationId)
 		if err != nil {
 			Log.Error(err, trace.GetTrace())
-			return
+			return nil, err
+		}
+
+		for _, login := range logins {
+			Log.Info(*login)
 		}
+
+		return fillBaseStructs(spec, opts, token, logins)
 	}
 
+	logins, err := getAllLogins()
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return nil, err
+	}
+
+	for _, login := range logins {
+		Log.Info(login)
+	}
+
+	return fillBaseStructsForAllLogins(spec, opts, logins)
+}
+
+// Run jobs until all jobs are processed.
+// If context is done, jobs which are not started are canceled
+func runJobs(ctx context.Context, structs []*BaseStruct) {
 	maxFor := 1000
 	currFor := 0
 	processed := false
@@ -120,90 +422,115 @@ func main() {
 	for !processed {
 		currFor++
 		Log.Info("Cycle " + strconv.Itoa(currFor))
+		canceled := ctx.Err() != nil
 		currProcessed := true
 		for i := 0; i < len(structs); i++ {
-			if !structs[i].Processed {
+			if !runJob(structs[i], canceled) {
 				currProcessed = false
 			}
-			if structs[i].Started || structs[i].Processed {
-				continue
-			}
-			if time.Now().After(structs[i].NextTry) {
-				structs[i].Try++
-				if structs[i].Try > AppConfig.Http.TryCount {
-					structs[i].Processed = true
-					continue
-				}
-				structs[i].Started = true
-				go getReportGo(structs[i])
-				/*
-					err = getReport(structs[i])
-					if err != nil {
-						structs[i].Processed = true
-						fmt.Println(err, trace.GetTrace())
-					}
-				*/
-			}
 		}
 		processed = currProcessed
 		if currFor > maxFor {
 			processed = true
 		}
-		time.Sleep(time.Second * 1)
+		if !processed {
+			time.Sleep(time.Second * 1)
+		}
 	}
 }
 
+// Start job if its next try time has come, cancel job if it is canceled and not started.
+// Returns true if job is processed
+func runJob(baseStruct *BaseStruct, canceled bool) bool {
+	baseStruct.mu.Lock()
+	defer baseStruct.mu.Unlock()
+	if (canceled || baseStruct.Canceled) && !baseStruct.Started && !baseStruct.Processed {
+		baseStruct.Processed = true
+		baseStruct.Failed = true
+		baseStruct.Error = "canceled"
+	}
+	if baseStruct.Processed {
+		notifyJobFailed(baseStruct, canceled)
+		return true
+	}
+	if baseStruct.Started {
+		return false
+	}
+	if time.Now().After(baseStruct.NextTry) {
+		baseStruct.Try++
+		if baseStruct.Try > AppConfig.Get().Http.TryCount {
+			baseStruct.Processed = true
+			baseStruct.Failed = true
+			baseStruct.Error = "try count exceeded"
+			notifyJobFailed(baseStruct, canceled)
+			return true
+		}
+		if baseStruct.Try == 1 {
+			baseStruct.FirstTry = time.Now()
+		} else {
+			metricRetries.With(baseStruct.Report).Inc()
+		}
+		baseStruct.Started = true
+		go getReportGo(baseStruct)
+	}
+	return false
+}
+
 // Process get report as goroutine
 func getReportGo(baseStruct *BaseStruct) {
 	err := getReport(baseStruct)
+	baseStruct.mu.Lock()
+	defer baseStruct.mu.Unlock()
 	if err != nil {
 		baseStruct.Processed = true
-		Log.Error(err, trace.GetTrace())
+		baseStruct.Failed = true
+		baseStruct.Error = err.Error()
+		jobLog(baseStruct).Error(err, trace.GetTrace())
 	}
 	baseStruct.Started = false
 }
 
-// Returns application config struct
-func getConfig() config.Config {
-	var appConfig config.Config
-	viper.SetConfigName("config.yml") // name of config file (without extension)
-	viper.SetConfigType("yaml")       // REQUIRED if the config file does not have the extension in the name
-	viper.AddConfigPath("./config/")  // path to look for the config file in
-	err := viper.ReadInConfig()       // Find and read the config file
-	if err != nil {                   // Handle errors reading the config file
-		panic(fmt.Errorf("fatal error config file: %w", err))
+// Returns validated application config struct, sets used config file
+func getConfig(path string) (config.Config, error) {
+	loader := config.NewLoader(path)
+	appConfig, err := loader.Load()
+	if err == nil {
+		err = appConfig.Validate()
 	}
-	err = viper.Unmarshal(&appConfig)
 	if err != nil {
-		panic(fmt.Errorf("unable to decode into struct, %w", err))
+		return appConfig, fmt.Errorf("invalid configuration:\n%w", err)
 	}
-	return appConfig
+	AppConfigFile = loader.File()
+	return appConfig, nil
 }
 
 // Get integration token from DB
 func getToken(intId int) (string, error) {
 	params := []any{intId}
 	tokenModel := integrations.Token{}
-	tokenAny, err := AppDb.QueryRow(tokenModel.GetDefaultSql(), params, &tokenModel)
+	token, err := db.QueryOne[integrations.Token](context.Background(), &AppDb, tokenModel.GetDefaultSql(), params)
 	if err != nil {
 		Log.Error(err, trace.GetTrace())
 		return "", err
 	}
-	token := tokenAny.(*integrations.Token)
 	if token.Token == "" {
 		err = errors.New("token not found")
 		Log.Error(err, trace.GetTrace())
 		return "", err
 	}
-	fmt.Println(token.Token)
-	return token.Token, nil
+	value, err := resolveStoredSecret(token.Token)
+	if err != nil {
+		Log.Error(err, trace.GetTrace())
+		return "", err
+	}
+	return effectiveToken(intId, value), nil
 }
 
 // Get integration logins from DB
 func getLogins(intId int) ([]*ydirectlogins.IntegrationLogin, error) {
 	params := []any{intId}
 	loginModel := ydirectlogins.IntegrationLogin{}
-	logins, err := AppDb.Query(loginModel.GetDefaultSql(), params, &loginModel)
+	logins, err := db.QueryAll[ydirectlogins.IntegrationLogin](context.Background(), &AppDb, loginModel.GetDefaultSql(), params)
 	if err != nil {
 		Log.Error(err, trace.GetTrace())
 		return nil, err
@@ -214,14 +541,14 @@ func getLogins(intId int) ([]*ydirectlogins.IntegrationLogin, error) {
 		return nil, err
 	}
 
-	return loginModel.ToType(logins), nil
+	return logins, nil
 }
 
 // Get all logins from DB
 func getAllLogins() ([]*ydirectlogins.AllIntegrationsLogin, error) {
 	params := []any{}
 	loginModel := ydirectlogins.AllIntegrationsLogin{}
-	logins, err := AppDb.Query(loginModel.GetDefaultSql(), params, &loginModel)
+	logins, err := db.QueryAll[ydirectlogins.AllIntegrationsLogin](context.Background(), &AppDb, loginModel.GetDefaultSql(), params)
 	if err != nil {
 		Log.Error(err, trace.GetTrace())
 		return nil, err
@@ -230,116 +557,167 @@ func getAllLogins() ([]*ydirectlogins.AllIntegrationsLogin, error) {
 		Log.Error("Logins not found!", trace.GetTrace())
 		return nil, err
 	}
-	return loginModel.ToType(logins), nil
+	for _, login := range logins {
+		login.Token, err = resolveStoredSecret(login.Token)
+		if err != nil {
+			Log.Error(err, "integration", login.IntegrationId, trace.GetTrace())
+			return nil, err
+		}
+		login.Token = effectiveToken(login.IntegrationId, login.Token)
+	}
+	return logins, nil
+}
+
+// Returns report spec dates relative to now
+func reportDates(spec config.Report, now time.Time) (string, string) {
+	return timezones.Date(now, spec.DateFrom), timezones.Date(now, spec.DateTo)
 }
 
 // Fill base struct data slice from all logins
-func fillBaseStructsForAllLogins(logins []*ydirectlogins.AllIntegrationsLogin) ([]*BaseStruct, error) {
-	header := template.TemplateManager{}
-	err := header.SetTemplate("./templates/header.json")
+func fillBaseStructsForAllLogins(spec config.Report, opts LoadOptions, logins []*ydirectlogins.AllIntegrationsLogin) ([]*BaseStruct, error) {
+	header, err := AppTemplates.Get("header.json")
 	if err != nil {
 		Log.Error(err, trace.GetTrace())
 		return nil, err
 	}
-	body := template.TemplateManager{}
-	err = body.SetTemplate("./templates/body.json")
+	body, err := AppTemplates.Get(spec.Template)
 	if err != nil {
 		Log.Error(err, trace.GetTrace())
 		return nil, err
 	}
-	yesterday := time.Now().Add(-24 * time.Hour)
 	headerMap := make(map[string]string)
 	headerMap["@AuthorizationToken"] = ""
 	headerMap["@Client-Login"] = ""
 	bodyMap := make(map[string]string)
-	bodyMap["@DateFrom"] = yesterday.Format("2006-01-02")
-	bodyMap["@DateTo"] = yesterday.Format("2006-01-02")
 	bodyMap["@ReportName"] = ""
 	structs := []*BaseStruct{}
 	for _, login := range logins {
+		if !opts.hasLogin(login.Login) {
+			continue
+		}
 		headerMap["@AuthorizationToken"] = login.Token
 		headerMap["@Client-Login"] = login.Login
-		bodyMap["@ReportName"] = strconv.FormatInt(rand.Int63(), 10)
+		bodyMap["@DateFrom"], bodyMap["@DateTo"] = opts.dates(spec, time.Now().In(loginLocation(login.Login, login.Token)))
 		structs = append(structs, &BaseStruct{
-			Token:     login.Token,
-			Login:     login.Login,
-			Headers:   header.Process(headerMap),
-			Body:      body.Process(bodyMap),
-			Processed: false,
-			Started:   false,
-			NextTry:   time.Now().Add(-1 * time.Second),
-			Try:       0,
+			IntegrationId: login.IntegrationId,
+			Token:         login.Token,
+			Login:         login.Login,
+			DateFrom:      bodyMap["@DateFrom"],
+			DateTo:        bodyMap["@DateTo"],
+			Headers:       header.Process(headerMap),
+			Body:          body.Process(bodyMap),
+			Processed:     false,
+			Started:       false,
+			NextTry:       time.Now().Add(-1 * time.Second),
+			Try:           0,
 		})
 	}
 	return structs, nil
 }
 
 // Fill base struct data slice for integration logins
-func fillBaseStructs(token string, logins []*ydirectlogins.IntegrationLogin) ([]*BaseStruct, error) {
-	header := template.TemplateManager{}
-	err := header.SetTemplate("./templates/header.json")
+func fillBaseStructs(spec config.Report, opts LoadOptions, token string, logins []*ydirectlogins.IntegrationLogin) ([]*BaseStruct, error) {
+	header, err := AppTemplates.Get("header.json")
 	if err != nil {
 		Log.Error(err, trace.GetTrace())
 		return nil, err
 	}
-	body := template.TemplateManager{}
-	err = body.SetTemplate("./templates/body.json")
+	body, err := AppTemplates.Get(spec.Template)
 	if err != nil {
 		Log.Error(err, trace.GetTrace())
 		return nil, err
 	}
-	yesterday := time.Now().Add(-24 * time.Hour)
 	headerMap := make(map[string]string)
 	headerMap["@AuthorizationToken"] = token
 	headerMap["@Client-Login"] = ""
 	bodyMap := make(map[string]string)
-	bodyMap["@DateFrom"] = yesterday.Format("2006-01-02")
-	bodyMap["@DateTo"] = yesterday.Format("2006-01-02")
 	bodyMap["@ReportName"] = ""
 	structs := []*BaseStruct{}
 	for _, login := range logins {
+		if !opts.hasLogin(login.Login) {
+			continue
+		}
 		headerMap["@Client-Login"] = login.Login
-		bodyMap["@ReportName"] = strconv.FormatInt(rand.Int63(), 10)
+		bodyMap["@DateFrom"], bodyMap["@DateTo"] = opts.dates(spec, time.Now().In(loginLocation(login.Login, token)))
 		structs = append(structs, &BaseStruct{
-			Login:     login.Login,
-			Headers:   header.Process(headerMap),
-			Body:      body.Process(bodyMap),
-			Processed: false,
-			NextTry:   time.Now(),
+			IntegrationId: IntegrationId,
+			Token:         token,
+			Login:         login.Login,
+			DateFrom:      bodyMap["@DateFrom"],
+			DateTo:        bodyMap["@DateTo"],
+			Headers:       header.Process(headerMap),
+			Body:          body.Process(bodyMap),
+			Processed:     false,
+			NextTry:       time.Now(),
 		})
 	}
 	return structs, nil
 }
 
 // Get report data from YD API
-func getReport(baseStruct *BaseStruct) error {
-	Log.Info("Get report start", baseStruct.Login)
+func getReport(baseStruct *BaseStruct) (err error) {
+	jobLog(baseStruct).Info("Get report start")
+	attempt := newAttempt(baseStruct)
+	defer func() {
+		addAttempt(attempt, err)
+	}()
 	path, err := createDir(baseStruct)
 	if err != nil {
-		Log.Error(err, trace.GetTrace())
+		jobLog(baseStruct).Error(err, trace.GetTrace())
 		return err
 	}
+	started := time.Now()
 	resp, err := post(baseStruct)
+	observeResponse(baseStruct, resp, time.Since(started))
 	if err != nil {
-		Log.Error(err, trace.GetTrace())
+		jobLog(baseStruct).Error(err, trace.GetTrace())
 		return err
 	}
-	err = writeFileResp(path, resp)
+	attempt.StatusCode = resp.StatusCode
+	attempt.Bytes = int64(len(resp.Body))
+	attempt.RetryIn, _ = strconv.Atoi(resp.Header.Get("Retryin"))
+	attempt.SetUnits(resp.Header.Get("Units"))
+	resp.Request = newRequestStruct(baseStruct)
+	attempt.FilePath, err = writeFileResp(path, resp)
 	if err != nil {
-		Log.Error(err, trace.GetTrace())
+		jobLog(baseStruct).Error(err, trace.GetTrace())
 		return err
 	}
-	err = processResp(baseStruct, resp)
+	// Tables are written before job is processed, so quality checks are in run summary
+	var tablesErr error
+	if resp.StatusCode == 200 {
+		tablesErr = writeTables(baseStruct, attempt.FilePath, resp)
+		if tablesErr != nil {
+			jobLog(baseStruct).Error(tablesErr, trace.GetTrace())
+		}
+	}
+	// Token is refreshed before job is locked, so jobs runner and admin API are not blocked by refresh
+	apiErr := direct.ParseError([]byte(resp.Body))
+	refreshed := ""
+	if resp.StatusCode != 200 && direct.IsAuthError(resp.StatusCode, apiErr) {
+		refreshed = handleAuthError(baseStruct, apiErr)
+	}
+	baseStruct.mu.Lock()
+	baseStruct.RequestId = resp.Header.Get("RequestId")
+	baseStruct.FilePath = attempt.FilePath
+	err = processResp(baseStruct, resp, refreshed)
+	notifyJobFailed(baseStruct, false)
+	baseStruct.mu.Unlock()
 	if err != nil {
-		Log.Error(err, trace.GetTrace())
+		jobLog(baseStruct).Error(err, trace.GetTrace())
 		return err
 	}
-	Log.Info("Get report end", baseStruct.Login)
+	// Blocked report is not cached, so it is loaded again by the next run
+	if resp.StatusCode == 200 && !errors.Is(tablesErr, errQualityBlocked) {
+		putCache(baseStruct, attempt.FilePath)
+	}
+	jobLog(baseStruct).Info("Get report end")
 	return nil
 }
 
-// Process response data
-func processResp(baseStruct *BaseStruct, resp *RespStruct) error {
+// Process response data, refreshed is new token of job after authorization error.
+// Job must be locked by caller
+func processResp(baseStruct *BaseStruct, resp *RespStruct, refreshed string) error {
 	switch resp.StatusCode {
 	case 200:
 		baseStruct.Processed = true
@@ -348,7 +726,7 @@ func processResp(baseStruct *BaseStruct, resp *RespStruct) error {
 		retryin, err := strconv.Atoi(resp.Header.Get("Retryin"))
 		if err != nil {
 			baseStruct.Processed = true
-			Log.Error(err, trace.GetTrace())
+			jobLog(baseStruct).Error(err, trace.GetTrace())
 			return err
 		}
 		baseStruct.NextTry = time.Now().Add(time.Duration(retryin) * time.Second)
@@ -357,7 +735,7 @@ func processResp(baseStruct *BaseStruct, resp *RespStruct) error {
 		retryin, err := strconv.Atoi(resp.Header.Get("Retryin"))
 		if err != nil {
 			baseStruct.Processed = true
-			Log.Error(err, trace.GetTrace())
+			jobLog(baseStruct).Error(err, trace.GetTrace())
 			return err
 		}
 		baseStruct.NextTry = time.Now().Add(time.Duration(retryin) * time.Second)
@@ -366,41 +744,139 @@ func processResp(baseStruct *BaseStruct, resp *RespStruct) error {
 		baseStruct.NextTry = time.Now().Add(time.Duration(1) * time.Second)
 		return nil
 	}
+	apiErr := direct.ParseError([]byte(resp.Body))
+	if refreshed != "" && setJobToken(baseStruct, refreshed) == nil {
+		baseStruct.NextTry = time.Now()
+		return nil
+	}
 	baseStruct.Processed = true
+	baseStruct.Failed = true
+	baseStruct.Error = resp.Status
+	if apiErr != nil {
+		baseStruct.Error = apiErr.Error()
+	}
 	return nil
 }
 
 // Write file with response data
-func writeFileResp(path string, resp *RespStruct) error {
+// Returns file name
+func writeFileResp(path string, resp *RespStruct) (string, error) {
 	respJson, err := json.MarshalIndent(resp, "", "  ")
 	if err != nil {
 		Log.Error(err, trace.GetTrace())
-		return err
+		return "", err
 	}
-	err = writeFile(path, respJson)
+	filename, err := writeFile(path, respJson)
 	if err != nil {
 		Log.Error(err, trace.GetTrace())
-		return err
+		return "", err
 	}
-	return nil
+	return filename, nil
 }
 
-// Write file
-func writeFile(path string, content []byte) error {
-	filename := filepath.Join(path, time.Now().Format("20060102150405")+".txt")
-	err := os.WriteFile(filename, content, 0777)
+// Returns request of job with masked token
+func newRequestStruct(baseStruct *BaseStruct) *RequestStruct {
+	baseStruct.mu.Lock()
+	defer baseStruct.mu.Unlock()
+	headers := baseStruct.Headers
+	if baseStruct.Token != "" {
+		headers = strings.ReplaceAll(headers, baseStruct.Token, secret.Mask(baseStruct.Token))
+	}
+	return &RequestStruct{
+		Report:     baseStruct.Report,
+		Login:      baseStruct.Login,
+		ReportName: baseStruct.ReportName,
+		DateFrom:   baseStruct.DateFrom,
+		DateTo:     baseStruct.DateTo,
+		Headers:    headers,
+		Body:       baseStruct.Body,
+	}
+}
+
+// Check data quality rules of report and write tables of report next to response file: goals table and money table.
+// Report blocked by quality rules is not written, errQualityBlocked is returned
+func writeTables(baseStruct *BaseStruct, filePath string, resp *RespStruct) error {
+	request := report.Request{}
+	err := json.Unmarshal([]byte(baseStruct.Body), &request)
 	if err != nil {
-		Log.Error(err, trace.GetTrace())
 		return err
 	}
-	return nil
+	hasGoals := len(request.Params.Goals) > 0
+	if !hasGoals && !AppConfig.Get().Money.Table && !jobQuality(baseStruct).Enabled() {
+		return nil
+	}
+	baseStruct.mu.Lock()
+	headersJson := baseStruct.Headers
+	baseStruct.mu.Unlock()
+	headers := map[string]string{}
+	err = json.Unmarshal([]byte(headersJson), &headers)
+	if err != nil {
+		return err
+	}
+	table, err := report.ParseTSV(resp.Body, headers)
+	if err != nil {
+		return err
+	}
+	err = checkQuality(baseStruct, table)
+	if errors.Is(err, errQualityBlocked) {
+		return err
+	}
+	if hasGoals {
+		err = writeGoals(baseStruct, filePath, table)
+		if err != nil {
+			return err
+		}
+	}
+	return writeMoney(baseStruct, filePath, table, request.Params.IncludeVAT == "YES")
+}
+
+// Max count of files with the same name written in directory, e.g. in one second
+const maxSameNameFiles = 1000
+
+// Write file named by current time
+// Returns file name
+func writeFile(path string, content []byte) (string, error) {
+	return writeNewFile(path, time.Now().Format("20060102150405"), ".txt", content)
+}
+
+// Write new file <name>[-N]<ext> in directory. Existing file is not overwritten, next number is used instead,
+// so jobs and runs writing files of login in the same second keep every file.
+// Returns file name
+func writeNewFile(path string, name string, ext string, content []byte) (string, error) {
+	for i := 0; i < maxSameNameFiles; i++ {
+		filename := filepath.Join(path, name+ext)
+		if i > 0 {
+			filename = filepath.Join(path, name+"-"+strconv.Itoa(i)+ext)
+		}
+		file, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0777)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			Log.Error(err, trace.GetTrace())
+			return "", err
+		}
+		_, err = file.Write(content)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			Log.Error(err, trace.GetTrace())
+			return "", err
+		}
+		return filename, nil
+	}
+	err := fmt.Errorf("too many files %s*%s in %s", name, ext, path)
+	Log.Error(err, trace.GetTrace())
+	return "", err
 }
 
 // Get data from report service
 func post(baseStruct *BaseStruct) (*RespStruct, error) {
 	body := bytes.NewBuffer([]byte(baseStruct.Body))
-	c := http.Client{Timeout: time.Duration(AppConfig.Http.Timeout) * time.Second}
-	req, err := http.NewRequest("POST", AppConfig.Http.ReportsUrl, body)
+	httpConfig := AppConfig.Get().Http
+	c := http.Client{Timeout: time.Duration(httpConfig.Timeout) * time.Second}
+	req, err := http.NewRequest("POST", AppConfig.Get().ReportsUrl(), body)
 	if err != nil {
 		Log.Error(err, trace.GetTrace())
 		return nil, err
@@ -414,6 +890,7 @@ func post(baseStruct *BaseStruct) (*RespStruct, error) {
 	for k, v := range headers {
 		req.Header.Add(k, v)
 	}
+	req.Header.Set("returnMoneyInMicros", strconv.FormatBool(AppConfig.Get().Money.Micros))
 	resp, err := c.Do(req)
 	if err != nil {
 		Log.Error(err, trace.GetTrace())
@@ -426,19 +903,20 @@ func post(baseStruct *BaseStruct) (*RespStruct, error) {
 		return nil, err
 	}
 	return &RespStruct{
-		Header:     resp.Header.Clone(),
-		Body:       string(respBody),
-		Status:     resp.Status,
-		StatusCode: resp.StatusCode,
+		Environment: AppConfig.Get().Environment,
+		Header:      resp.Header.Clone(),
+		Body:        string(respBody),
+		Status:      resp.Status,
+		StatusCode:  resp.StatusCode,
 	}, nil
 }
 
 // Create input data directory if needed
 func createDir(baseStruct *BaseStruct) (string, error) {
-	path := InputDir + baseStruct.Login
+	path := inputDir() + baseStruct.Login
 	if _, err := os.Stat(path); err != nil {
 		if os.IsNotExist(err) {
-			err := os.Mkdir(path, 0777)
+			err := os.MkdirAll(path, 0777)
 			if err != nil {
 				Log.Error(err, trace.GetTrace())
 				return "", err
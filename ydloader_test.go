@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteNewFile(t *testing.T) {
+	dir := t.TempDir()
+	want := []string{"20240501101010.txt", "20240501101010-1.txt", "20240501101010-2.txt"}
+	for i, name := range want {
+		content := []byte{byte('a' + i)}
+		filename, err := writeNewFile(dir, "20240501101010", ".txt", content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filename != filepath.Join(dir, name) {
+			t.Errorf("file %d = %s, want %s", i, filename, name)
+		}
+	}
+	for i, name := range want {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != string(rune('a'+i)) {
+			t.Errorf("%s = %q, content of file %d is overwritten", name, data, i)
+		}
+	}
+}